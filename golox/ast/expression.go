@@ -47,7 +47,8 @@ func (se StringExpression) expressionNode() {}
 
 type AssignExpression struct {
 	Name  string
-	Value any
+	Pos   token.Position
+	Value Expression
 }
 
 func (n *AssignExpression) Type() NodeType {
@@ -59,6 +60,7 @@ func (ae *AssignExpression) expressionNode() {}
 // Binary
 type BinaryExpression struct {
 	Operator token.Type
+	Pos      token.Position // position of the operator
 	Left     Expression
 	Right    Expression
 }
@@ -69,8 +71,22 @@ func (n *BinaryExpression) Type() NodeType {
 
 func (be *BinaryExpression) expressionNode() {}
 
+// LogicalExpression is a short-circuiting 'and' or 'or' expression
+type LogicalExpression struct {
+	Operator token.Type
+	Left     Expression
+	Right    Expression
+}
+
+func (le *LogicalExpression) Type() NodeType {
+	return Logical
+}
+
+func (le *LogicalExpression) expressionNode() {}
+
 type UrnaryExpression struct {
 	Operator token.Type
+	Pos      token.Position // position of the operator
 	Right    Expression
 }
 
@@ -80,6 +96,45 @@ func (ue *UrnaryExpression) Type() NodeType {
 
 func (ue *UrnaryExpression) expressionNode() {}
 
+type CallExpression struct {
+	Callee    Expression
+	Arguments []Expression
+	Pos       token.Position // position of the closing parenthesis
+}
+
+func (ce *CallExpression) Type() NodeType {
+	return Call
+}
+
+func (ce *CallExpression) expressionNode() {}
+
+// GetExpression reads a property of an instance
+type GetExpression struct {
+	Object Expression
+	Name   string
+	Pos    token.Position
+}
+
+func (ge *GetExpression) Type() NodeType {
+	return Get
+}
+
+func (ge *GetExpression) expressionNode() {}
+
+// SetExpression assigns to a field of an instance
+type SetExpression struct {
+	Object Expression
+	Name   string
+	Pos    token.Position
+	Value  Expression
+}
+
+func (se *SetExpression) Type() NodeType {
+	return Set
+}
+
+func (se *SetExpression) expressionNode() {}
+
 type GroupingExpression struct {
 	Expression Expression
 }
@@ -90,12 +145,37 @@ func (ge *GroupingExpression) Type() NodeType {
 
 func (ge *GroupingExpression) expressionNode() {}
 
+// VariableExpression, ThisExpression and SuperExpression are pointers, because
+// the resolver identifies each occurrence by its node.
 type VariableExpression struct {
 	Name string
+	Pos  token.Position
 }
 
-func (ve VariableExpression) Type() NodeType {
+func (ve *VariableExpression) Type() NodeType {
 	return Variable
 }
 
-func (ve VariableExpression) expressionNode() {}
+func (ve *VariableExpression) expressionNode() {}
+
+type ThisExpression struct {
+	Pos token.Position
+}
+
+func (te *ThisExpression) Type() NodeType {
+	return This
+}
+
+func (te *ThisExpression) expressionNode() {}
+
+// SuperExpression looks up a method on the superclass of the enclosing class
+type SuperExpression struct {
+	Method string
+	Pos    token.Position
+}
+
+func (se *SuperExpression) Type() NodeType {
+	return Super
+}
+
+func (se *SuperExpression) expressionNode() {}
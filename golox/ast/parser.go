@@ -1,57 +1,202 @@
 package ast
 
 import (
-	"errors"
 	"fmt"
 
 	"github.com/cornelmarck/crafting-interpreters/golox/token"
 )
 
 // Parser is a recursive descent parser.
-// The main todo is implementing syntax validation and error handling.
+//
+// A syntax error abandons the declaration it occurs in. The parser then
+// synchronizes at the next statement boundary and continues, so a single
+// pass reports every syntax error in the source.
+
+// maxArguments is the maximum number of parameters or arguments of a function
+const maxArguments = 255
 
 type Parser struct {
-	tokens  []token.Token
-	current token.Token
-	offset  int
+	tokens   []token.Token
+	current  token.Token
+	previous token.Token // most recently consumed token
+	offset   int
+
+	errors token.ErrorList
 }
 
 func NewParser(tokens []token.Token) *Parser {
-	return &Parser{
+	p := &Parser{
 		tokens:  tokens,
 		current: tokens[0],
 		offset:  0,
 	}
+	// illegal tokens are reported by the scanner
+	p.skipIgnored()
+	return p
 }
 
+// Parse returns the statements that parsed cleanly, together with the list of
+// syntax errors if there were any.
 func (p *Parser) Parse() ([]Statement, error) {
 	var statements []Statement
 	for !p.eof() {
-		statement, err := p.declaration()
-		if err != nil {
-			return statements, err
+		if statement := p.declaration(); statement != nil {
+			statements = append(statements, statement)
 		}
-		statements = append(statements, statement)
 	}
-	return statements, nil
+	p.errors.Sort()
+	return statements, p.errors.Err()
+}
+
+// ParseExpression parses the tokens as a single expression without a
+// trailing semicolon, as entered at the prompt.
+func (p *Parser) ParseExpression() (Expression, error) {
+	expression, err := p.expression()
+	if err == nil && !p.eof() {
+		err = p.error(p.current, "Expect end of expression.")
+	}
+	if err != nil {
+		p.errors = append(p.errors, err.(*token.Error))
+	}
+	p.errors.Sort()
+	if err := p.errors.Err(); err != nil {
+		return nil, err
+	}
+	return expression, nil
 }
 
 // Declaration
 
-func (p *Parser) declaration() (Statement, error) {
+// declaration returns nil if the declaration has a syntax error. The error is
+// recorded and the parser skips ahead to the start of the next statement.
+func (p *Parser) declaration() Statement {
+	statement, err := p.parseDeclaration()
+	if err != nil {
+		p.errors = append(p.errors, err.(*token.Error))
+		p.synchronize()
+		return nil
+	}
+	return statement
+}
+
+func (p *Parser) parseDeclaration() (Statement, error) {
 	if p.match(token.Var) {
 		p.next()
 		return p.parseVarDeclaration()
 	}
+	if p.match(token.Fun) {
+		p.next()
+		return p.function("function")
+	}
+	if p.match(token.Class) {
+		p.next()
+		return p.classDeclaration()
+	}
 	return p.statement()
 }
 
+func (p *Parser) classDeclaration() (Statement, error) {
+	name, err := p.consume(token.Identifier, "Expect class name.")
+	if err != nil {
+		return nil, err
+	}
+
+	var superclass *VariableExpression
+	if p.match(token.Less) {
+		p.next()
+		if !p.match(token.Identifier) {
+			return nil, p.error(p.current, "Expect superclass name.")
+		}
+		superclass = &VariableExpression{
+			Name: p.current.Literal.(string),
+			Pos:  p.current.Pos,
+		}
+		if superclass.Name == name.Literal.(string) {
+			p.report(p.current, "A class can't inherit from itself.")
+		}
+		p.next()
+	}
+
+	if _, err := p.consume(token.LeftBrace, "Expect '{' before class body."); err != nil {
+		return nil, err
+	}
+	var methods []FunctionDeclaration
+	for !p.match(token.RightBrace) && !p.eof() {
+		method, err := p.function("method")
+		if err != nil {
+			return nil, err
+		}
+		methods = append(methods, method)
+	}
+	if _, err := p.consume(token.RightBrace, "Expect '}' after class body."); err != nil {
+		return nil, err
+	}
+
+	return ClassDeclaration{
+		Name:       name.Literal.(string),
+		Pos:        name.Pos,
+		Superclass: superclass,
+		Methods:    methods,
+	}, nil
+}
+
+// function parses the name, parameters and body of a function. The kind is
+// used in error messages.
+func (p *Parser) function(kind string) (FunctionDeclaration, error) {
+	name, err := p.consume(token.Identifier, fmt.Sprintf("Expect %s name.", kind))
+	if err != nil {
+		return FunctionDeclaration{}, err
+	}
+
+	if _, err := p.consume(token.LeftParen, fmt.Sprintf("Expect '(' after %s name.", kind)); err != nil {
+		return FunctionDeclaration{}, err
+	}
+	var params []Identifier
+	if !p.match(token.RightParen) {
+		for {
+			if len(params) >= maxArguments {
+				p.report(p.current, fmt.Sprintf("Can't have more than %d parameters.", maxArguments))
+			}
+			param, err := p.consume(token.Identifier, "Expect parameter name.")
+			if err != nil {
+				return FunctionDeclaration{}, err
+			}
+			params = append(params, Identifier{
+				Name: param.Literal.(string),
+				Pos:  param.Pos,
+			})
+
+			if !p.match(token.Comma) {
+				break
+			}
+			p.next()
+		}
+	}
+	if _, err := p.consume(token.RightParen, "Expect ')' after parameters."); err != nil {
+		return FunctionDeclaration{}, err
+	}
+
+	if _, err := p.consume(token.LeftBrace, fmt.Sprintf("Expect '{' before %s body.", kind)); err != nil {
+		return FunctionDeclaration{}, err
+	}
+	body, err := p.block()
+	if err != nil {
+		return FunctionDeclaration{}, err
+	}
+
+	return FunctionDeclaration{
+		Name:   name.Literal.(string),
+		Pos:    name.Pos,
+		Params: params,
+		Body:   body,
+	}, nil
+}
+
 func (p *Parser) parseVarDeclaration() (Statement, error) {
-	if !p.match(token.Identifier) {
-		return nil, errors.New("missing identifier in variable declaration")
+	identifier, err := p.consume(token.Identifier, "Expect variable name.")
+	if err != nil {
+		return nil, err
 	}
-	identifier := p.current
-	p.next()
 
 	var initializer Expression
 	if p.match(token.Equal) {
@@ -63,12 +208,12 @@ func (p *Parser) parseVarDeclaration() (Statement, error) {
 		initializer = expression
 	}
 
-	if !p.match(token.Semicolon) {
-		return nil, errors.New("expected ';' after variable declaration")
+	if _, err := p.consume(token.Semicolon, "Expect ';' after variable declaration."); err != nil {
+		return nil, err
 	}
-	p.next()
 	return VariableDeclaration{
 		Name:        identifier.Literal.(string),
+		Pos:         identifier.Pos,
 		Initializer: initializer,
 	}, nil
 }
@@ -80,14 +225,43 @@ func (p *Parser) statement() (Statement, error) {
 		p.next()
 		return p.print()
 	}
+	if p.match(token.LeftBrace) {
+		p.next()
+		statements, err := p.block()
+		if err != nil {
+			return nil, err
+		}
+		return BlockStatement{
+			Statements: statements,
+		}, nil
+	}
+	if p.match(token.If) {
+		p.next()
+		return p.ifStatement()
+	}
+	if p.match(token.While) {
+		p.next()
+		return p.whileStatement()
+	}
+	if p.match(token.For) {
+		p.next()
+		return p.forStatement()
+	}
+	if p.match(token.Return) {
+		p.next()
+		return p.returnStatement()
+	}
+	return p.expressionStatement()
+}
+
+func (p *Parser) expressionStatement() (Statement, error) {
 	expression, err := p.expression()
 	if err != nil {
 		return nil, err
 	}
-	if !p.match(token.Semicolon) {
-		return nil, errors.New("expected ';' after expression statement")
+	if _, err := p.consume(token.Semicolon, "Expect ';' after expression."); err != nil {
+		return nil, err
 	}
-	p.next()
 
 	return ExpressionStatement{
 		Expression: expression,
@@ -103,21 +277,271 @@ func (p *Parser) print() (Statement, error) {
 	node := PrintStatement{
 		Expression: expression,
 	}
-	if !p.match(token.Semicolon) {
-		return nil, errors.New("expected ';' after print statement")
+	if _, err := p.consume(token.Semicolon, "Expect ';' after value."); err != nil {
+		return nil, err
 	}
-	p.next()
 	return node, nil
 }
 
+// block parses the statements up to the closing brace, it is shared by block
+// statements and function bodies
+func (p *Parser) block() ([]Statement, error) {
+	// opening { is already consumed
+	var statements []Statement
+	for !p.match(token.RightBrace) && !p.eof() {
+		if statement := p.declaration(); statement != nil {
+			statements = append(statements, statement)
+		}
+	}
+
+	if _, err := p.consume(token.RightBrace, "Expect '}' after block."); err != nil {
+		return nil, err
+	}
+	return statements, nil
+}
+
+func (p *Parser) ifStatement() (Statement, error) {
+	if _, err := p.consume(token.LeftParen, "Expect '(' after 'if'."); err != nil {
+		return nil, err
+	}
+	condition, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.consume(token.RightParen, "Expect ')' after if condition."); err != nil {
+		return nil, err
+	}
+
+	thenBranch, err := p.statement()
+	if err != nil {
+		return nil, err
+	}
+
+	// the else is bound to the nearest preceding if
+	var elseBranch Statement
+	if p.match(token.Else) {
+		p.next()
+		elseBranch, err = p.statement()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return IfStatement{
+		Condition: condition,
+		Then:      thenBranch,
+		Else:      elseBranch,
+	}, nil
+}
+
+func (p *Parser) whileStatement() (Statement, error) {
+	// while keyword is already consumed
+	keyword := p.previous
+
+	if _, err := p.consume(token.LeftParen, "Expect '(' after 'while'."); err != nil {
+		return nil, err
+	}
+	condition, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.consume(token.RightParen, "Expect ')' after condition."); err != nil {
+		return nil, err
+	}
+
+	body, err := p.statement()
+	if err != nil {
+		return nil, err
+	}
+	return WhileStatement{
+		Pos:       keyword.Pos,
+		Condition: condition,
+		Body:      body,
+	}, nil
+}
+
+// forStatement has no node of its own, it is desugared into a while loop:
+//
+//	{ initializer; while (condition) { body; increment; } }
+func (p *Parser) forStatement() (Statement, error) {
+	// for keyword is already consumed
+	keyword := p.previous
+
+	if _, err := p.consume(token.LeftParen, "Expect '(' after 'for'."); err != nil {
+		return nil, err
+	}
+
+	var initializer Statement
+	var err error
+	switch {
+	case p.match(token.Semicolon):
+		p.next()
+	case p.match(token.Var):
+		p.next()
+		initializer, err = p.parseVarDeclaration()
+	default:
+		initializer, err = p.expressionStatement()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var condition Expression = BooleanExpression{Value: true}
+	if !p.match(token.Semicolon) {
+		condition, err = p.expression()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if _, err := p.consume(token.Semicolon, "Expect ';' after loop condition."); err != nil {
+		return nil, err
+	}
+
+	var increment Expression
+	if !p.match(token.RightParen) {
+		increment, err = p.expression()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if _, err := p.consume(token.RightParen, "Expect ')' after for clauses."); err != nil {
+		return nil, err
+	}
+
+	body, err := p.statement()
+	if err != nil {
+		return nil, err
+	}
+
+	if increment != nil {
+		body = BlockStatement{
+			Statements: []Statement{body, ExpressionStatement{Expression: increment}},
+		}
+	}
+	body = WhileStatement{
+		Pos:       keyword.Pos,
+		Condition: condition,
+		Body:      body,
+	}
+	if initializer != nil {
+		body = BlockStatement{
+			Statements: []Statement{initializer, body},
+		}
+	}
+	return body, nil
+}
+
+func (p *Parser) returnStatement() (Statement, error) {
+	// return keyword is already consumed
+	keyword := p.previous
+
+	var value Expression
+	if !p.match(token.Semicolon) {
+		var err error
+		value, err = p.expression()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := p.consume(token.Semicolon, "Expect ';' after return value."); err != nil {
+		return nil, err
+	}
+	return ReturnStatement{
+		Value: value,
+		Pos:   keyword.Pos,
+	}, nil
+}
+
 // Expressions
 
 func (p *Parser) expression() (Expression, error) {
-	return p.equality()
+	return p.assignment()
 }
 
 func (p *Parser) assignment() (Expression, error) {
-	return nil, nil
+	target, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.match(token.Equal) {
+		return target, nil
+	}
+	equals := p.current
+	p.next()
+
+	// assignment is right-associative, so the value is parsed recursively
+	value, err := p.assignment()
+	if err != nil {
+		return nil, err
+	}
+
+	switch target := target.(type) {
+	case *VariableExpression:
+		return &AssignExpression{
+			Name:  target.Name,
+			Pos:   target.Pos,
+			Value: value,
+		}, nil
+	case *GetExpression:
+		return &SetExpression{
+			Object: target.Object,
+			Name:   target.Name,
+			Pos:    target.Pos,
+			Value:  value,
+		}, nil
+	default:
+		// the parser is not confused, so there is no need to synchronize
+		p.report(equals, "Invalid assignment target.")
+		return target, nil
+	}
+}
+
+func (p *Parser) or() (Expression, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.match(token.Or) {
+		operator := p.current.Type
+		p.next()
+
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left = &LogicalExpression{
+			Operator: operator,
+			Left:     left,
+			Right:    right,
+		}
+	}
+	return left, nil
+}
+
+func (p *Parser) and() (Expression, error) {
+	left, err := p.equality()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.match(token.And) {
+		operator := p.current.Type
+		p.next()
+
+		right, err := p.equality()
+		if err != nil {
+			return nil, err
+		}
+		left = &LogicalExpression{
+			Operator: operator,
+			Left:     left,
+			Right:    right,
+		}
+	}
+	return left, nil
 }
 
 func (p *Parser) equality() (Expression, error) {
@@ -127,7 +551,7 @@ func (p *Parser) equality() (Expression, error) {
 	}
 
 	for p.match(token.EqualEqual, token.BangEqual) {
-		operator := p.current.Type
+		operator := p.current
 		p.next()
 
 		right, err := p.comparison()
@@ -135,7 +559,8 @@ func (p *Parser) equality() (Expression, error) {
 			return nil, err
 		}
 		left = &BinaryExpression{
-			Operator: operator,
+			Operator: operator.Type,
+			Pos:      operator.Pos,
 			Left:     left,
 			Right:    right,
 		}
@@ -150,7 +575,7 @@ func (p *Parser) comparison() (Expression, error) {
 	}
 
 	for p.match(token.Greater, token.GreaterEqual, token.Less, token.LessEqual) {
-		operator := p.current.Type
+		operator := p.current
 		p.next()
 
 		right, err := p.term()
@@ -158,7 +583,8 @@ func (p *Parser) comparison() (Expression, error) {
 			return nil, err
 		}
 		left = &BinaryExpression{
-			Operator: operator,
+			Operator: operator.Type,
+			Pos:      operator.Pos,
 			Left:     left,
 			Right:    right,
 		}
@@ -173,7 +599,7 @@ func (p *Parser) term() (Expression, error) {
 	}
 
 	for p.match(token.Minus, token.Plus) {
-		operator := p.current.Type
+		operator := p.current
 		p.next()
 
 		right, err := p.factor()
@@ -181,7 +607,8 @@ func (p *Parser) term() (Expression, error) {
 			return nil, err
 		}
 		left = &BinaryExpression{
-			Operator: operator,
+			Operator: operator.Type,
+			Pos:      operator.Pos,
 			Left:     left,
 			Right:    right,
 		}
@@ -196,15 +623,16 @@ func (p *Parser) factor() (Expression, error) {
 	}
 
 	for p.match(token.Slash, token.Star) {
-		operator := p.current.Type
+		operator := p.current
 		p.next()
 
-		right, err := p.factor()
+		right, err := p.urnary()
 		if err != nil {
 			return nil, err
 		}
 		left = &BinaryExpression{
-			Operator: operator,
+			Operator: operator.Type,
+			Pos:      operator.Pos,
 			Left:     left,
 			Right:    right,
 		}
@@ -214,7 +642,7 @@ func (p *Parser) factor() (Expression, error) {
 
 func (p *Parser) urnary() (Expression, error) {
 	if p.match(token.Bang, token.Minus) {
-		operator := p.current.Type
+		operator := p.current
 		p.next()
 
 		right, err := p.urnary()
@@ -222,41 +650,123 @@ func (p *Parser) urnary() (Expression, error) {
 			return nil, err
 		}
 		return &UrnaryExpression{
-			Operator: operator,
+			Operator: operator.Type,
+			Pos:      operator.Pos,
 			Right:    right,
 		}, nil
 	}
-	return p.primary()
+	return p.call()
+}
+
+func (p *Parser) call() (Expression, error) {
+	expression, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.match(token.LeftParen, token.Dot) {
+		if p.match(token.Dot) {
+			p.next()
+			name, err := p.consume(token.Identifier, "Expect property name after '.'.")
+			if err != nil {
+				return nil, err
+			}
+			expression = &GetExpression{
+				Object: expression,
+				Name:   name.Literal.(string),
+				Pos:    name.Pos,
+			}
+			continue
+		}
+
+		p.next()
+		expression, err = p.finishCall(expression)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return expression, nil
+}
+
+func (p *Parser) finishCall(callee Expression) (Expression, error) {
+	// opening ( is already consumed
+	var arguments []Expression
+	if !p.match(token.RightParen) {
+		for {
+			if len(arguments) >= maxArguments {
+				p.report(p.current, fmt.Sprintf("Can't have more than %d arguments.", maxArguments))
+			}
+			argument, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			arguments = append(arguments, argument)
+
+			if !p.match(token.Comma) {
+				break
+			}
+			p.next()
+		}
+	}
+
+	paren, err := p.consume(token.RightParen, "Expect ')' after arguments.")
+	if err != nil {
+		return nil, err
+	}
+	return &CallExpression{
+		Callee:    callee,
+		Arguments: arguments,
+		Pos:       paren.Pos,
+	}, nil
 }
 
 func (p *Parser) primary() (Expression, error) {
-	defer p.next()
+	tok := p.current
 
-	switch p.current.Type {
+	switch tok.Type {
 	case token.False:
+		p.next()
 		return BooleanExpression{Value: false}, nil
 	case token.True:
+		p.next()
 		return BooleanExpression{Value: true}, nil
 	case token.Nil:
+		p.next()
 		return NilExpression{}, nil
 	case token.Number:
-		return NumberExpression{Value: p.current.Literal.(float64)}, nil
+		p.next()
+		return NumberExpression{Value: tok.Literal.(float64)}, nil
 	case token.String:
-		return StringExpression{Value: p.current.Literal.(string)}, nil
+		p.next()
+		return StringExpression{Value: tok.Literal.(string)}, nil
 	case token.LeftParen:
 		p.next()
 		grouping, err := p.expression()
 		if err != nil {
+			return nil, err
 		}
-
-		if !p.match(token.RightParen) {
-			return nil, errors.New("expected closing ')' after grouping expression")
+		if _, err := p.consume(token.RightParen, "Expect ')' after expression."); err != nil {
+			return nil, err
 		}
 		return &GroupingExpression{Expression: grouping}, nil
 	case token.Identifier:
-		return VariableExpression{Name: p.current.Literal.(string)}, nil
+		p.next()
+		return &VariableExpression{Name: tok.Literal.(string), Pos: tok.Pos}, nil
+	case token.This:
+		p.next()
+		return &ThisExpression{Pos: tok.Pos}, nil
+	case token.Super:
+		p.next()
+		if _, err := p.consume(token.Dot, "Expect '.' after 'super'."); err != nil {
+			return nil, err
+		}
+		method, err := p.consume(token.Identifier, "Expect superclass method name.")
+		if err != nil {
+			return nil, err
+		}
+		return &SuperExpression{Method: method.Literal.(string), Pos: tok.Pos}, nil
 	default:
-		return nil, fmt.Errorf("unexpected token: %s", p.current.Type.String())
+		return nil, p.error(tok, "Expect expression.")
 	}
 }
 
@@ -268,6 +778,16 @@ func (p *Parser) eof() bool {
 
 func (p *Parser) next() {
 	if p.current.Type != token.EOF {
+		p.previous = p.current
+		p.offset += 1
+		p.current = p.tokens[p.offset]
+		p.skipIgnored()
+	}
+}
+
+// skipIgnored skips the tokens that are not part of the grammar
+func (p *Parser) skipIgnored() {
+	for p.current.Type == token.Illegal || p.current.Type == token.Comment {
 		p.offset += 1
 		p.current = p.tokens[p.offset]
 	}
@@ -281,3 +801,45 @@ func (p *Parser) match(types ...token.Type) bool {
 	}
 	return false
 }
+
+// consume advances past the current token and returns it if it has the
+// expected type, and returns an error with the given message otherwise
+func (p *Parser) consume(t token.Type, message string) (token.Token, error) {
+	if !p.match(t) {
+		return token.Token{}, p.error(p.current, message)
+	}
+	p.next()
+	return p.previous, nil
+}
+
+// error creates a syntax error at the token
+func (p *Parser) error(tok token.Token, message string) error {
+	return &token.Error{
+		Pos:        tok.Pos,
+		Where:      token.At(tok),
+		Msg:        message,
+		Incomplete: tok.Type == token.EOF,
+	}
+}
+
+// report records a syntax error that the parser can continue from without
+// synchronizing
+func (p *Parser) report(tok token.Token, message string) {
+	p.errors = append(p.errors, p.error(tok, message).(*token.Error))
+}
+
+// synchronize discards tokens until the start of the next statement, so that
+// the errors following a syntax error are not cascades of the first one
+func (p *Parser) synchronize() {
+	p.next()
+	for !p.eof() {
+		if p.previous.Type == token.Semicolon {
+			return
+		}
+		switch p.current.Type {
+		case token.Class, token.Fun, token.Var, token.For, token.If, token.While, token.Print, token.Return:
+			return
+		}
+		p.next()
+	}
+}
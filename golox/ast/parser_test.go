@@ -60,6 +60,28 @@ func TestASTParser(t *testing.T) {
 					Right: NumberExpression{Value: 1},
 				},
 			},
+		}, {
+			Name: "8 / 4 * 2",
+			Tokens: []token.Token{
+				{Type: token.Number, Literal: float64(8)},
+				{Type: token.Slash},
+				{Type: token.Number, Literal: float64(4)},
+				{Type: token.Star},
+				{Type: token.Number, Literal: float64(2)},
+				{Type: token.Semicolon},
+				{Type: token.EOF},
+			},
+			Expected: ExpressionStatement{
+				Expression: &BinaryExpression{
+					Operator: token.Star,
+					Left: &BinaryExpression{
+						Operator: token.Slash,
+						Left:     NumberExpression{Value: 8},
+						Right:    NumberExpression{Value: 4},
+					},
+					Right: NumberExpression{Value: 2},
+				},
+			},
 		}, {
 			Name: "4 / (3 - 2)",
 			Tokens: []token.Token{
@@ -100,6 +122,169 @@ func TestASTParser(t *testing.T) {
 				Name:        "a",
 				Initializer: NumberExpression{Value: 2.3},
 			},
+		}, {
+			Name: "a = b = 1;",
+			Tokens: []token.Token{
+				{Type: token.Identifier, Literal: "a"},
+				{Type: token.Equal},
+				{Type: token.Identifier, Literal: "b"},
+				{Type: token.Equal},
+				{Type: token.Number, Literal: float64(1)},
+				{Type: token.Semicolon},
+				{Type: token.EOF},
+			},
+			Expected: ExpressionStatement{
+				Expression: &AssignExpression{
+					Name: "a",
+					Value: &AssignExpression{
+						Name:  "b",
+						Value: NumberExpression{Value: 1},
+					},
+				},
+			},
+		}, {
+			Name: "{ var a; }",
+			Tokens: []token.Token{
+				{Type: token.LeftBrace},
+				{Type: token.Var},
+				{Type: token.Identifier, Literal: "a"},
+				{Type: token.Semicolon},
+				{Type: token.RightBrace},
+				{Type: token.EOF},
+			},
+			Expected: BlockStatement{
+				Statements: []Statement{
+					VariableDeclaration{Name: "a"},
+				},
+			},
+		}, {
+			Name: "for (;;) print 1;",
+			Tokens: []token.Token{
+				{Type: token.For},
+				{Type: token.LeftParen},
+				{Type: token.Semicolon},
+				{Type: token.Semicolon},
+				{Type: token.RightParen},
+				{Type: token.Print},
+				{Type: token.Number, Literal: float64(1)},
+				{Type: token.Semicolon},
+				{Type: token.EOF},
+			},
+			Expected: WhileStatement{
+				Condition: BooleanExpression{Value: true},
+				Body:      PrintStatement{Expression: NumberExpression{Value: 1}},
+			},
+		}, {
+			Name: "a or b and c;",
+			Tokens: []token.Token{
+				{Type: token.Identifier, Literal: "a"},
+				{Type: token.Or},
+				{Type: token.Identifier, Literal: "b"},
+				{Type: token.And},
+				{Type: token.Identifier, Literal: "c"},
+				{Type: token.Semicolon},
+				{Type: token.EOF},
+			},
+			Expected: ExpressionStatement{
+				Expression: &LogicalExpression{
+					Operator: token.Or,
+					Left:     &VariableExpression{Name: "a"},
+					Right: &LogicalExpression{
+						Operator: token.And,
+						Left:     &VariableExpression{Name: "b"},
+						Right:    &VariableExpression{Name: "c"},
+					},
+				},
+			},
+		}, {
+			Name: "fun add(a, b) { return a + b; }",
+			Tokens: []token.Token{
+				{Type: token.Fun},
+				{Type: token.Identifier, Literal: "add"},
+				{Type: token.LeftParen},
+				{Type: token.Identifier, Literal: "a"},
+				{Type: token.Comma},
+				{Type: token.Identifier, Literal: "b"},
+				{Type: token.RightParen},
+				{Type: token.LeftBrace},
+				{Type: token.Return},
+				{Type: token.Identifier, Literal: "a"},
+				{Type: token.Plus},
+				{Type: token.Identifier, Literal: "b"},
+				{Type: token.Semicolon},
+				{Type: token.RightBrace},
+				{Type: token.EOF},
+			},
+			Expected: FunctionDeclaration{
+				Name:   "add",
+				Params: []Identifier{{Name: "a"}, {Name: "b"}},
+				Body: []Statement{
+					ReturnStatement{
+						Value: &BinaryExpression{
+							Operator: token.Plus,
+							Left:     &VariableExpression{Name: "a"},
+							Right:    &VariableExpression{Name: "b"},
+						},
+					},
+				},
+			},
+		}, {
+			Name: "f(1)();",
+			Tokens: []token.Token{
+				{Type: token.Identifier, Literal: "f"},
+				{Type: token.LeftParen},
+				{Type: token.Number, Literal: float64(1)},
+				{Type: token.RightParen},
+				{Type: token.LeftParen},
+				{Type: token.RightParen},
+				{Type: token.Semicolon},
+				{Type: token.EOF},
+			},
+			Expected: ExpressionStatement{
+				Expression: &CallExpression{
+					Callee: &CallExpression{
+						Callee:    &VariableExpression{Name: "f"},
+						Arguments: []Expression{NumberExpression{Value: 1}},
+					},
+				},
+			},
+		}, {
+			Name: "class Foo { bar() { this.baz = 1; } }",
+			Tokens: []token.Token{
+				{Type: token.Class},
+				{Type: token.Identifier, Literal: "Foo"},
+				{Type: token.LeftBrace},
+				{Type: token.Identifier, Literal: "bar"},
+				{Type: token.LeftParen},
+				{Type: token.RightParen},
+				{Type: token.LeftBrace},
+				{Type: token.This},
+				{Type: token.Dot},
+				{Type: token.Identifier, Literal: "baz"},
+				{Type: token.Equal},
+				{Type: token.Number, Literal: float64(1)},
+				{Type: token.Semicolon},
+				{Type: token.RightBrace},
+				{Type: token.RightBrace},
+				{Type: token.EOF},
+			},
+			Expected: ClassDeclaration{
+				Name: "Foo",
+				Methods: []FunctionDeclaration{
+					{
+						Name: "bar",
+						Body: []Statement{
+							ExpressionStatement{
+								Expression: &SetExpression{
+									Object: &ThisExpression{},
+									Name:   "baz",
+									Value:  NumberExpression{Value: 1},
+								},
+							},
+						},
+					},
+				},
+			},
 		},
 	} {
 		tc := tc
@@ -114,3 +299,94 @@ func TestASTParser(t *testing.T) {
 		})
 	}
 }
+
+func TestASTParserInvalidAssignment(t *testing.T) {
+	t.Parallel()
+
+	// a + b = 1;
+	p := NewParser([]token.Token{
+		{Type: token.Identifier, Literal: "a"},
+		{Type: token.Plus},
+		{Type: token.Identifier, Literal: "b"},
+		{Type: token.Equal},
+		{Type: token.Number, Literal: float64(1)},
+		{Type: token.Semicolon},
+		{Type: token.EOF},
+	})
+	_, err := p.Parse()
+	assert.ErrorContains(t, err, "Invalid assignment target.")
+}
+
+func TestASTParserParseExpression(t *testing.T) {
+	t.Parallel()
+
+	// 1 + 2
+	p := NewParser([]token.Token{
+		{Type: token.Number, Literal: float64(1)},
+		{Type: token.Plus},
+		{Type: token.Number, Literal: float64(2)},
+		{Type: token.EOF},
+	})
+	expression, err := p.ParseExpression()
+	assert.NoError(t, err)
+	assert.Equal(t, &BinaryExpression{
+		Operator: token.Plus,
+		Left:     NumberExpression{Value: 1},
+		Right:    NumberExpression{Value: 2},
+	}, expression)
+
+	// 1 2
+	p = NewParser([]token.Token{
+		{Type: token.Number, Literal: float64(1)},
+		{Type: token.Number, Literal: float64(2), Lexeme: "2"},
+		{Type: token.EOF},
+	})
+	_, err = p.ParseExpression()
+	assert.EqualError(t, err, "[line 0] Error at '2': Expect end of expression.")
+}
+
+func TestASTParserInheritFromSelf(t *testing.T) {
+	t.Parallel()
+
+	// class Foo < Foo {}
+	p := NewParser([]token.Token{
+		{Type: token.Class},
+		{Type: token.Identifier, Literal: "Foo"},
+		{Type: token.Less},
+		{Type: token.Identifier, Literal: "Foo"},
+		{Type: token.LeftBrace},
+		{Type: token.RightBrace},
+		{Type: token.EOF},
+	})
+	_, err := p.Parse()
+	assert.ErrorContains(t, err, "A class can't inherit from itself.")
+}
+
+func TestASTParserErrorRecovery(t *testing.T) {
+	t.Parallel()
+
+	src := "var a = ;\n" +
+		"print a;\n" +
+		"var = 2;\n" +
+		"print 1 2;\n" +
+		"print a |;\n"
+	tokens, _ := token.NewScanner([]byte(src)).Scan()
+	p := NewParser(tokens)
+	statements, err := p.Parse()
+
+	// the statements that parsed cleanly are returned
+	assert.Equal(t, []Statement{
+		PrintStatement{Expression: &VariableExpression{Name: "a", Pos: token.Position{Offset: 16, Line: 2, Column: 7}}},
+		PrintStatement{Expression: &VariableExpression{Name: "a", Pos: token.Position{Offset: 45, Line: 5, Column: 7}}},
+	}, statements)
+
+	var errs []string
+	for _, e := range err.(token.ErrorList) {
+		errs = append(errs, e.Error())
+	}
+	assert.Equal(t, []string{
+		"[line 1] Error at ';': Expect expression.",
+		"[line 3] Error at '=': Expect variable name.",
+		"[line 4] Error at '2': Expect ';' after value.",
+	}, errs)
+}
@@ -0,0 +1,138 @@
+package ast
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Fprint writes the syntax tree of the node as a parenthesized expression in
+// prefix notation, followed by a newline. For example, the statement
+// "print 1 + a;" is written as "(print (+ 1 a))".
+func Fprint(w io.Writer, node Node) error {
+	var sb strings.Builder
+	printNode(&sb, node)
+	sb.WriteByte('\n')
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func printNode(sb *strings.Builder, node Node) {
+	switch node := node.(type) {
+	// Expressions
+	case BooleanExpression:
+		sb.WriteString(strconv.FormatBool(node.Value))
+	case NilExpression:
+		sb.WriteString("nil")
+	case NumberExpression:
+		sb.WriteString(strconv.FormatFloat(node.Value, 'f', -1, 64))
+	case StringExpression:
+		sb.WriteString(strconv.Quote(node.Value))
+	case *VariableExpression:
+		sb.WriteString(node.Name)
+	case *ThisExpression:
+		sb.WriteString("this")
+	case *SuperExpression:
+		parenthesize(sb, "super", node.Method)
+	case *AssignExpression:
+		parenthesize(sb, "=", node.Name, node.Value)
+	case *GroupingExpression:
+		parenthesize(sb, "group", node.Expression)
+	case *UrnaryExpression:
+		parenthesize(sb, node.Operator.String(), node.Right)
+	case *BinaryExpression:
+		parenthesize(sb, node.Operator.String(), node.Left, node.Right)
+	case *LogicalExpression:
+		parenthesize(sb, node.Operator.String(), node.Left, node.Right)
+	case *CallExpression:
+		parts := []any{node.Callee}
+		for _, argument := range node.Arguments {
+			parts = append(parts, argument)
+		}
+		parenthesize(sb, "call", parts...)
+	case *GetExpression:
+		parenthesize(sb, ".", node.Object, node.Name)
+	case *SetExpression:
+		parenthesize(sb, "=", &GetExpression{Object: node.Object, Name: node.Name}, node.Value)
+
+	// Statements
+	case ExpressionStatement:
+		parenthesize(sb, ";", node.Expression)
+	case PrintStatement:
+		parenthesize(sb, "print", node.Expression)
+	case VariableDeclaration:
+		if node.Initializer == nil {
+			parenthesize(sb, "var", node.Name)
+		} else {
+			parenthesize(sb, "var", node.Name, node.Initializer)
+		}
+	case BlockStatement:
+		parenthesize(sb, "block", statements(node.Statements)...)
+	case IfStatement:
+		if node.Else == nil {
+			parenthesize(sb, "if", node.Condition, node.Then)
+		} else {
+			parenthesize(sb, "if", node.Condition, node.Then, node.Else)
+		}
+	case WhileStatement:
+		parenthesize(sb, "while", node.Condition, node.Body)
+	case FunctionDeclaration:
+		printFunction(sb, "fun", node)
+	case ReturnStatement:
+		if node.Value == nil {
+			parenthesize(sb, "return")
+		} else {
+			parenthesize(sb, "return", node.Value)
+		}
+	case ClassDeclaration:
+		sb.WriteString("(class ")
+		sb.WriteString(node.Name)
+		if node.Superclass != nil {
+			sb.WriteString(" < ")
+			sb.WriteString(node.Superclass.Name)
+		}
+		for _, method := range node.Methods {
+			sb.WriteByte(' ')
+			printFunction(sb, "method", method)
+		}
+		sb.WriteByte(')')
+	default:
+		fmt.Fprintf(sb, "(unknown %s)", reflect.TypeOf(node))
+	}
+}
+
+func printFunction(sb *strings.Builder, kind string, node FunctionDeclaration) {
+	params := make([]string, len(node.Params))
+	for i, param := range node.Params {
+		params[i] = param.Name
+	}
+	parts := []any{node.Name, "(" + strings.Join(params, " ") + ")"}
+	parenthesize(sb, kind, append(parts, statements(node.Body)...)...)
+}
+
+// parenthesize writes the name and parts as a list. A part is either a node
+// or a string that is written as is.
+func parenthesize(sb *strings.Builder, name string, parts ...any) {
+	sb.WriteByte('(')
+	sb.WriteString(name)
+	for _, part := range parts {
+		sb.WriteByte(' ')
+		switch part := part.(type) {
+		case string:
+			sb.WriteString(part)
+		case Node:
+			printNode(sb, part)
+		}
+	}
+	sb.WriteByte(')')
+}
+
+func statements(nodes []Statement) []any {
+	parts := make([]any, len(nodes))
+	for i, node := range nodes {
+		parts[i] = node
+	}
+	return parts
+}
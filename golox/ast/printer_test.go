@@ -0,0 +1,57 @@
+package ast
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cornelmarck/crafting-interpreters/golox/token"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrint(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		Src      string
+		Expected string
+	}{
+		{
+			Src:      `print -1 + 2 * (3 - a);`,
+			Expected: `(print (+ (- 1) (* 2 (group (- 3 a)))))`,
+		}, {
+			Src:      `var s = "hi" or nil and !true;`,
+			Expected: `(var s (or "hi" (and nil (! true))))`,
+		}, {
+			Src:      `var a; a = b.c = f(1, 2.5);`,
+			Expected: "(var a)\n(; (= a (= (. b c) (call f 1 2.5))))",
+		}, {
+			Src:      `if (x) { return; } else while (y) print y;`,
+			Expected: `(if x (block (return)) (while y (print y)))`,
+		}, {
+			Src:      `fun add(a, b) { return a + b; }`,
+			Expected: `(fun add (a b) (return (+ a b)))`,
+		}, {
+			Src:      `class B < A { init() { this.x = super.y(); } }`,
+			Expected: `(class B < A (method init () (; (= (. this x) (call (super y))))))`,
+		},
+	} {
+		tc := tc
+
+		t.Run(tc.Src, func(t *testing.T) {
+			t.Parallel()
+
+			tokens, err := token.NewScanner([]byte(tc.Src)).Scan()
+			require.NoError(t, err)
+			statements, err := NewParser(tokens).Parse()
+			require.NoError(t, err)
+
+			var sb strings.Builder
+			for _, statement := range statements {
+				require.NoError(t, Fprint(&sb, statement))
+			}
+			assert.Equal(t, tc.Expected+"\n", sb.String())
+		})
+	}
+}
@@ -1,5 +1,7 @@
 package ast
 
+import "github.com/cornelmarck/crafting-interpreters/golox/token"
+
 type Statement interface {
 	Node
 	statementNode()
@@ -17,6 +19,7 @@ func (ps PrintStatement) statementNode() {}
 
 type VariableDeclaration struct {
 	Name        string
+	Pos         token.Position
 	Initializer Expression
 }
 
@@ -35,3 +38,81 @@ func (es ExpressionStatement) Type() NodeType {
 }
 
 func (es ExpressionStatement) statementNode() {}
+
+type BlockStatement struct {
+	Statements []Statement
+}
+
+func (bs BlockStatement) Type() NodeType {
+	return Block
+}
+
+func (bs BlockStatement) statementNode() {}
+
+type IfStatement struct {
+	Condition Expression
+	Then      Statement
+	Else      Statement // nil if there is no else branch
+}
+
+func (is IfStatement) Type() NodeType {
+	return If
+}
+
+func (is IfStatement) statementNode() {}
+
+// WhileStatement is also the desugared form of a for loop
+type WhileStatement struct {
+	Pos       token.Position // position of the while or for keyword
+	Condition Expression
+	Body      Statement
+}
+
+func (ws WhileStatement) Type() NodeType {
+	return While
+}
+
+func (ws WhileStatement) statementNode() {}
+
+type FunctionDeclaration struct {
+	Name   string
+	Pos    token.Position
+	Params []Identifier
+	Body   []Statement
+}
+
+// Identifier is a name together with the position it is declared at
+type Identifier struct {
+	Name string
+	Pos  token.Position
+}
+
+func (fd FunctionDeclaration) Type() NodeType {
+	return Function
+}
+
+func (fd FunctionDeclaration) statementNode() {}
+
+type ReturnStatement struct {
+	Value Expression // nil if no value is returned
+	Pos   token.Position
+}
+
+func (rs ReturnStatement) Type() NodeType {
+	return Return
+}
+
+func (rs ReturnStatement) statementNode() {}
+
+type ClassDeclaration struct {
+	Name       string
+	Pos        token.Position
+	Superclass *VariableExpression // nil if the class does not inherit
+	Methods    []FunctionDeclaration
+}
+
+func (cd ClassDeclaration) Type() NodeType {
+	return Class
+}
+
+func (cd ClassDeclaration) statementNode() {}
@@ -0,0 +1,333 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/cornelmarck/crafting-interpreters/golox/ast"
+	"github.com/cornelmarck/crafting-interpreters/golox/format"
+	"github.com/cornelmarck/crafting-interpreters/golox/interpreter"
+	"github.com/cornelmarck/crafting-interpreters/golox/readline"
+	"github.com/cornelmarck/crafting-interpreters/golox/token"
+)
+
+// cli runs golox commands. The standard streams are fields, so commands can
+// be run against buffers.
+type cli struct {
+	stdin  io.Reader
+	stdout io.Writer // program output
+	stderr io.Writer // diagnostics and help
+}
+
+// command is a subcommand of golox
+type command struct {
+	name    string
+	args    string // synopsis of the arguments
+	summary string
+	run     func(c *cli, flags *flag.FlagSet, args []string) int
+}
+
+var commands = []command{
+	{"run", "<script>", "run a script", (*cli).run},
+	{"repl", "", "start an interactive session", (*cli).repl},
+	{"tokens", "<file>...", "print the tokens of the files", (*cli).tokens},
+	{"ast", "<file>...", "print the syntax trees of the files", (*cli).ast},
+	{"check", "<file>...", "report the static errors of the files without running them", (*cli).check},
+	{"fmt", "<file>...", "format the files", (*cli).fmt},
+}
+
+// main runs the command named by the first argument and returns the exit
+// code. Without arguments it starts the REPL, or runs the script piped to
+// stdin. For compatibility a single script argument runs the script.
+func (c *cli) main(args []string) int {
+	if len(args) == 0 {
+		if readline.IsTerminal(c.stdin) {
+			return c.exec("repl", nil)
+		}
+		return c.exec("run", []string{stdinName})
+	}
+
+	switch args[0] {
+	case "help", "-h", "-help", "--help":
+		if len(args) > 1 {
+			if _, ok := lookup(args[1]); ok {
+				return c.exec(args[1], []string{"-h"})
+			}
+		}
+		c.usage()
+		return 0
+	}
+	if _, ok := lookup(args[0]); ok {
+		return c.exec(args[0], args[1:])
+	}
+	if len(args) == 1 && (args[0] == stdinName || !strings.HasPrefix(args[0], "-")) {
+		return c.exec("run", args)
+	}
+
+	fmt.Fprintf(c.stderr, "golox: unknown command %s\n", args[0])
+	c.usage()
+	return exitUsage
+}
+
+// exec runs the named command with its own flag set
+func (c *cli) exec(name string, args []string) int {
+	cmd, _ := lookup(name)
+	return cmd.run(c, c.flags(cmd), args)
+}
+
+func lookup(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return command{}, false
+}
+
+func (c *cli) usage() {
+	fmt.Fprint(c.stderr, "usage: golox <command> [flags] [arguments]\n\ncommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(c.stderr, "  %-8s%s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprint(c.stderr, "\nRun \"golox help <command>\" for the flags of a command.\n")
+}
+
+// flags returns the flag set of the command, which prints the usage of the
+// command on -h or on invalid flags
+func (c *cli) flags(cmd command) *flag.FlagSet {
+	flags := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	flags.SetOutput(c.stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(c.stderr, "usage: golox %s [flags] %s\n\n%s.\n", cmd.name, cmd.args, cmd.summary)
+		if cmd.args != "" {
+			fmt.Fprintf(c.stderr, "A file named %s is read from stdin.\n", stdinName)
+		}
+		var hasFlags bool
+		flags.VisitAll(func(*flag.Flag) { hasFlags = true })
+		if hasFlags {
+			fmt.Fprint(c.stderr, "\nflags:\n")
+			flags.PrintDefaults()
+		}
+	}
+	return flags
+}
+
+// parse parses the arguments of the command and checks the number of
+// remaining arguments is in [min, max], max < 0 meaning unbounded. It returns
+// the exit code if the command should not run.
+func parse(flags *flag.FlagSet, args []string, min, max int) (int, bool) {
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0, false
+		}
+		return exitUsage, false
+	}
+	if n := flags.NArg(); n < min || (max >= 0 && n > max) {
+		flags.Usage()
+		return exitUsage, false
+	}
+	return 0, true
+}
+
+// run runs a script. The program output goes to stdout, diagnostics go to
+// stderr and the exit code tells which phase failed.
+func (c *cli) run(flags *flag.FlagSet, args []string) int {
+	timeout := flags.Duration("timeout", 0, "stop the script after the duration, 0 for no limit")
+	if code, ok := parse(flags, args, 1, 1); !ok {
+		return code
+	}
+
+	src, err := c.readFile(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(c.stderr, "could not read script: %v\n", err)
+		return exitIOErr
+	}
+
+	interpreter := interpreter.New(c.stdout, interpreter.WithStdin(c.stdin), interpreter.WithStderr(c.stderr))
+	statements, err := compile(interpreter, src)
+	if err != nil {
+		reportError(c.stderr, err)
+		return exitDataErr
+	}
+
+	ctx := context.Background()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+	if err := interpreter.InterpretContext(ctx, statements...); err != nil {
+		reportError(c.stderr, err)
+		return exitSoftware
+	}
+	return 0
+}
+
+func (c *cli) repl(flags *flag.FlagSet, args []string) int {
+	history := flags.String("history", defaultHistoryFile(), "file to keep the input history in, empty to not keep it")
+	timing := flags.Bool("time", false, "report how long each statement takes to run")
+	if code, ok := parse(flags, args, 0, 0); !ok {
+		return code
+	}
+
+	fmt.Fprintln(c.stdout, "Enter text (Ctrl+D to stop)")
+	repl := newREPL(c.stdout, c.stderr)
+	repl.historyFile = *history
+	repl.timing = *timing
+	repl.run(c.stdin)
+	return 0
+}
+
+func defaultHistoryFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".golox_history")
+}
+
+func (c *cli) tokens(flags *flag.FlagSet, args []string) int {
+	comments := flags.Bool("comments", false, "include comments")
+	if code, ok := parse(flags, args, 1, -1); !ok {
+		return code
+	}
+
+	return c.eachFile(flags.Args(), func(src []byte) error {
+		s := token.NewScanner(src)
+		if *comments {
+			s.Mode = token.ScanComments
+		}
+		tokens, err := s.Scan()
+		printTokens(c.stdout, tokens)
+		return err
+	})
+}
+
+func (c *cli) ast(flags *flag.FlagSet, args []string) int {
+	if code, ok := parse(flags, args, 1, -1); !ok {
+		return code
+	}
+
+	return c.eachFile(flags.Args(), func(src []byte) error {
+		tokens, scanErr := token.NewScanner(src).Scan()
+		statements, parseErr := ast.NewParser(tokens).Parse()
+		if err := errors.Join(scanErr, parseErr); err != nil {
+			return err
+		}
+		for _, statement := range statements {
+			if err := ast.Fprint(c.stdout, statement); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (c *cli) check(flags *flag.FlagSet, args []string) int {
+	if code, ok := parse(flags, args, 1, -1); !ok {
+		return code
+	}
+
+	return c.eachFile(flags.Args(), func(src []byte) error {
+		_, err := compile(interpreter.New(io.Discard), src)
+		return err
+	})
+}
+
+func (c *cli) fmt(flags *flag.FlagSet, args []string) int {
+	write := flags.Bool("w", false, "write the result to the file instead of stdout")
+	list := flags.Bool("l", false, "list the files whose formatting differs")
+	if code, ok := parse(flags, args, 1, -1); !ok {
+		return code
+	}
+
+	if *write && slices.Contains(flags.Args(), stdinName) {
+		fmt.Fprintln(c.stderr, "can not write the result to stdin")
+		return exitUsage
+	}
+
+	code := 0
+	for _, file := range flags.Args() {
+		src, err := c.readFile(file)
+		if err != nil {
+			fmt.Fprintf(c.stderr, "could not read file: %v\n", err)
+			code = max(code, exitIOErr)
+			continue
+		}
+		formatted, err := format.Source(src)
+		if err != nil {
+			reportFileError(c.stderr, file, err)
+			code = max(code, exitDataErr)
+			continue
+		}
+
+		changed := !bytes.Equal(src, formatted)
+		if *list && changed {
+			fmt.Fprintln(c.stdout, file)
+		}
+		if *write {
+			if changed {
+				if err := os.WriteFile(file, formatted, 0o644); err != nil {
+					fmt.Fprintf(c.stderr, "could not write file: %v\n", err)
+					code = max(code, exitIOErr)
+				}
+			}
+		} else if !*list {
+			c.stdout.Write(formatted)
+		}
+	}
+	return code
+}
+
+// stdinName is the file name that stands for stdin
+const stdinName = "-"
+
+// readFile reads the named file, or stdin if the name is stdinName
+func (c *cli) readFile(name string) ([]byte, error) {
+	if name == stdinName {
+		return io.ReadAll(c.stdin)
+	}
+	return os.ReadFile(name)
+}
+
+// eachFile calls fn with the source of each file and reports the errors it
+// returns. The exit code reflects the worst failure.
+func (c *cli) eachFile(files []string, fn func(src []byte) error) int {
+	code := 0
+	for _, file := range files {
+		src, err := c.readFile(file)
+		if err != nil {
+			fmt.Fprintf(c.stderr, "could not read file: %v\n", err)
+			code = max(code, exitIOErr)
+			continue
+		}
+		if err := fn(src); err != nil {
+			reportFileError(c.stderr, file, err)
+			code = max(code, exitDataErr)
+		}
+	}
+	return code
+}
+
+// reportFileError prints the errors like reportError, each prefixed by the
+// name of the file they occurred in
+func reportFileError(w io.Writer, file string, err error) {
+	if file == stdinName {
+		file = "<stdin>"
+	}
+	var buf bytes.Buffer
+	reportError(&buf, err)
+	for _, line := range strings.SplitAfter(buf.String(), "\n") {
+		if line != "" {
+			fmt.Fprintf(w, "%s: %s", file, line)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCLI(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"ok.lox":      "var a=1;\nprint a+2;\n",
+		"syntax.lox":  "print 1",
+		"runtime.lox": "print -\"a\";",
+		"trace.lox":   "fun inner() {\n  return -nil;\n}\nfun outer() { inner(); }\nouter();\n",
+		"shebang.lox": "#!/usr/bin/env golox\nprint 1;\n",
+		"loop.lox":    "while (true) {}",
+	}
+	for name, src := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644))
+	}
+
+	for _, tc := range []struct {
+		Name   string
+		Args   []string
+		Stdin  string
+		Code   int
+		Stdout string
+		Stderr string // prefix of the diagnostics
+	}{
+		{
+			Name:   "run",
+			Args:   []string{"run", "ok.lox"},
+			Stdout: "3\n",
+		}, {
+			Name:   "script without command",
+			Args:   []string{"ok.lox"},
+			Stdout: "3\n",
+		}, {
+			Name:   "shebang",
+			Args:   []string{"shebang.lox"},
+			Stdout: "1\n",
+		}, {
+			Name:   "script from stdin",
+			Args:   []string{"run", "-"},
+			Stdin:  "print 1;",
+			Stdout: "1\n",
+		}, {
+			Name:   "piped script",
+			Stdin:  "print 2;",
+			Stdout: "2\n",
+		}, {
+			Name:   "syntax error",
+			Args:   []string{"run", "syntax.lox"},
+			Code:   exitDataErr,
+			Stderr: "[line 1] Error at end: Expect ';' after value.\n",
+		}, {
+			Name:   "runtime error",
+			Args:   []string{"run", "runtime.lox"},
+			Code:   exitSoftware,
+			Stderr: "Operand must be a number.\n[line 1]\n",
+		}, {
+			Name: "stack trace",
+			Args: []string{"run", "trace.lox"},
+			Code: exitSoftware,
+			Stderr: "Operand must be a number.\n[line 2]\n" +
+				"[line 2] in inner()\n[line 4] in outer()\n[line 5] in script\n",
+		}, {
+			Name:   "timeout",
+			Args:   []string{"run", "-timeout", "10ms", "loop.lox"},
+			Code:   exitSoftware,
+			Stderr: "Execution canceled: context deadline exceeded.\n[line 1]\n",
+		}, {
+			Name:   "missing script",
+			Args:   []string{"run", "missing.lox"},
+			Code:   exitIOErr,
+			Stderr: "could not read script:",
+		}, {
+			Name:   "tokens",
+			Args:   []string{"tokens", "syntax.lox"},
+			Stdout: "1:1\tprint\t\"print\"\n1:7\tnumber\t\"1\"\n1:8\teof\t\"\"\n",
+		}, {
+			Name:   "ast",
+			Args:   []string{"ast", "ok.lox"},
+			Stdout: "(var a 1)\n(print (+ a 2))\n",
+		}, {
+			Name: "check",
+			Args: []string{"check", "ok.lox", "runtime.lox"},
+		}, {
+			Name:   "check reports the file",
+			Args:   []string{"check", "ok.lox", "syntax.lox"},
+			Code:   exitDataErr,
+			Stderr: "syntax.lox: [line 1] Error at end: Expect ';' after value.\n",
+		}, {
+			Name:   "check stdin",
+			Args:   []string{"check", "-"},
+			Stdin:  "print 1",
+			Code:   exitDataErr,
+			Stderr: "<stdin>: [line 1] Error at end: Expect ';' after value.\n",
+		}, {
+			Name:   "fmt",
+			Args:   []string{"fmt", "ok.lox"},
+			Stdout: "var a = 1;\nprint a + 2;\n",
+		}, {
+			Name:   "fmt list",
+			Args:   []string{"fmt", "-l", "ok.lox"},
+			Stdout: "ok.lox\n",
+		}, {
+			Name:   "fmt can not write stdin",
+			Args:   []string{"fmt", "-w", "-"},
+			Code:   exitUsage,
+			Stderr: "can not write the result to stdin\n",
+		}, {
+			Name:   "help",
+			Args:   []string{"help"},
+			Stderr: "usage: golox <command>",
+		}, {
+			Name:   "command help",
+			Args:   []string{"check", "-h"},
+			Stderr: "usage: golox check [flags] <file>...",
+		}, {
+			Name:   "unknown flag",
+			Args:   []string{"fmt", "-x", "ok.lox"},
+			Code:   exitUsage,
+			Stderr: "flag provided but not defined: -x\nusage: golox fmt",
+		}, {
+			Name:   "missing arguments",
+			Args:   []string{"tokens"},
+			Code:   exitUsage,
+			Stderr: "usage: golox tokens",
+		}, {
+			Name:   "unknown command",
+			Args:   []string{"compile", "ok.lox"},
+			Code:   exitUsage,
+			Stderr: "golox: unknown command compile\n",
+		},
+	} {
+		tc := tc
+
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			args := make([]string, len(tc.Args))
+			for i, arg := range tc.Args {
+				if _, ok := files[arg]; ok {
+					arg = filepath.Join(dir, arg)
+				}
+				args[i] = arg
+			}
+
+			var stdout, stderr bytes.Buffer
+			c := &cli{stdin: strings.NewReader(tc.Stdin), stdout: &stdout, stderr: &stderr}
+			code := c.main(args)
+
+			assert.Equal(t, tc.Code, code)
+			assert.Equal(t, tc.Stdout, strings.ReplaceAll(stdout.String(), dir+string(filepath.Separator), ""))
+			stderrOut := strings.ReplaceAll(stderr.String(), dir+string(filepath.Separator), "")
+			assert.True(t, strings.HasPrefix(stderrOut, tc.Stderr), stderrOut)
+			if tc.Stderr == "" {
+				assert.Empty(t, stderrOut)
+			}
+		})
+	}
+}
+
+func TestCLIFmtWrite(t *testing.T) {
+	t.Parallel()
+
+	file := filepath.Join(t.TempDir(), "script.lox")
+	require.NoError(t, os.WriteFile(file, []byte("print  1+2 ;"), 0o644))
+
+	var stdout, stderr bytes.Buffer
+	c := &cli{stdout: &stdout, stderr: &stderr}
+	assert.Equal(t, 0, c.main([]string{"fmt", "-w", file}))
+	assert.Empty(t, stdout.String())
+
+	formatted, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, "print 1 + 2;\n", string(formatted))
+}
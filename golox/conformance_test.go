@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/cornelmarck/crafting-interpreters/testdata"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// skipped lists the test cases golox does not pass yet, keyed by chapter
+// directory or by file. Remove an entry once the feature is implemented.
+var skipped = map[string]string{
+	// the book runs these against the builds of earlier chapters
+	"scanning":    "tests the scanner chapter, which prints tokens",
+	"expressions": "tests the expression chapter, which evaluates a bare expression",
+
+	// clox only
+	"limit/loop_too_large.lox":     "clox jump limit",
+	"limit/no_reuse_constants.lox": "clox constant table limit",
+	"limit/too_many_constants.lox": "clox constant table limit",
+	"limit/too_many_locals.lox":    "clox local slot limit",
+	"limit/too_many_upvalues.lox":  "clox upvalue limit",
+
+	"benchmark": "benchmarks are slow",
+}
+
+var (
+	expectOutput       = regexp.MustCompile(`// expect: ?(.*)`)
+	expectError        = regexp.MustCompile(`// (Error.*)`)
+	expectErrorLine    = regexp.MustCompile(`// \[((java|c) )?line (\d+)\] (Error.*)`)
+	expectRuntimeError = regexp.MustCompile(`// expect runtime error: (.+)`)
+)
+
+// expectation is what a test case annotates in its comments
+type expectation struct {
+	output        []string
+	compileErrors []string
+	runtimeError  string // message and line, as printed by cli.run
+}
+
+// code is the exit code of golox run for the script
+func (e expectation) code() int {
+	switch {
+	case len(e.compileErrors) > 0:
+		return exitDataErr
+	case e.runtimeError != "":
+		return exitSoftware
+	default:
+		return 0
+	}
+}
+
+func parseExpectation(src []byte) expectation {
+	var exp expectation
+	scanner := bufio.NewScanner(bytes.NewReader(src))
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+
+		if m := expectOutput.FindStringSubmatch(text); m != nil {
+			exp.output = append(exp.output, m[1])
+		} else if m := expectErrorLine.FindStringSubmatch(text); m != nil {
+			// errors marked for the other implementation do not apply
+			if m[2] == "" || m[2] == "java" {
+				exp.compileErrors = append(exp.compileErrors, fmt.Sprintf("[line %s] %s", m[3], m[4]))
+			}
+		} else if m := expectError.FindStringSubmatch(text); m != nil {
+			exp.compileErrors = append(exp.compileErrors, fmt.Sprintf("[line %d] %s", line, m[1]))
+		} else if m := expectRuntimeError.FindStringSubmatch(text); m != nil {
+			exp.runtimeError = fmt.Sprintf("%s\n[line %d]", m[1], line)
+		}
+	}
+	return exp
+}
+
+// result is the observable behaviour of running a script
+type result struct {
+	code          int
+	output        []string
+	compileErrors []string
+	runtimeError  string
+}
+
+// runScript runs the script with golox run, reading it from stdin, and
+// collects the exit code, the output and the diagnostics
+func runScript(src []byte) result {
+	var stdout, stderr bytes.Buffer
+	c := &cli{stdin: bytes.NewReader(src), stdout: &stdout, stderr: &stderr}
+	res := result{
+		code:   c.main([]string{"run", stdinName}),
+		output: lines(stdout.String()),
+	}
+
+	diagnostics := lines(stderr.String())
+	switch res.code {
+	case 0:
+	case exitSoftware:
+		// the message and the line, the stack trace follows
+		res.runtimeError = strings.Join(diagnostics[:min(2, len(diagnostics))], "\n")
+	default:
+		res.compileErrors = diagnostics
+	}
+	return res
+}
+
+// lines splits the text into lines without their line endings
+func lines(s string) []string {
+	if s = strings.TrimSuffix(s, "\n"); s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+func skipReason(name string) (string, bool) {
+	if reason, ok := skipped[name]; ok {
+		return reason, true
+	}
+	reason, ok := skipped[path.Dir(name)]
+	return reason, ok
+}
+
+func TestConformance(t *testing.T) {
+	t.Parallel()
+
+	err := fs.WalkDir(testdata.TestCases, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		src, err := fs.ReadFile(testdata.TestCases, name)
+		if err != nil {
+			return err
+		}
+
+		t.Run(strings.TrimSuffix(name, ".lox"), func(t *testing.T) {
+			t.Parallel()
+
+			if reason, ok := skipReason(name); ok {
+				t.Skip(reason)
+			}
+
+			exp := parseExpectation(src)
+			res := runScript(src)
+
+			assert.Equal(t, exp.code(), res.code, "exit code")
+			assert.Equal(t, exp.compileErrors, res.compileErrors, "compile errors")
+			assert.Equal(t, exp.runtimeError, res.runtimeError, "runtime error")
+			assert.Equal(t, exp.output, res.output, "output")
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestParseExpectation covers the annotation formats of the test suite
+func TestParseExpectation(t *testing.T) {
+	t.Parallel()
+
+	src := strings.Join([]string{
+		`print 1; // expect: 1`,
+		`print "";   // expect: `,
+		`// [java line 3] Error at 'b': Expect ')' after arguments.`,
+		`// [c line 5] Error at end: Expect '}' after block.`,
+		`var a = ; // Error at ';': Expect expression.`,
+		`a(); // expect runtime error: Undefined variable 'a'.`,
+	}, "\n")
+
+	assert.Equal(t, expectation{
+		output: []string{"1", ""},
+		compileErrors: []string{
+			"[line 3] Error at 'b': Expect ')' after arguments.",
+			"[line 5] Error at ';': Expect expression.",
+		},
+		runtimeError: "Undefined variable 'a'.\n[line 6]",
+	}, parseExpectation([]byte(src)))
+}
@@ -0,0 +1,157 @@
+// Package format formats Lox source code in a canonical style: one statement
+// per line, blocks indented by two spaces and binary operators surrounded by
+// spaces. Comments and single blank lines between statements are kept.
+package format
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+
+	"github.com/cornelmarck/crafting-interpreters/golox/ast"
+	"github.com/cornelmarck/crafting-interpreters/golox/token"
+)
+
+// indent is the indentation of a single block level
+const indent = "  "
+
+// Source formats the source. The source must be free of syntax errors, the
+// formatter lays out the tokens and relies on the parser to validate them.
+func Source(src []byte) ([]byte, error) {
+	s := token.NewScanner(src)
+	s.Mode = token.ScanComments
+	tokens, scanErr := s.Scan()
+	_, parseErr := ast.NewParser(tokens).Parse()
+	if err := errors.Join(scanErr, parseErr); err != nil {
+		return nil, err
+	}
+
+	f := &formatter{}
+	for _, tok := range tokens {
+		if tok.Type == token.EOF {
+			break
+		}
+		f.token(tok)
+	}
+	if f.started {
+		f.buf.WriteByte('\n')
+	}
+	return f.buf.Bytes(), nil
+}
+
+type formatter struct {
+	buf bytes.Buffer
+
+	depth   int  // block nesting depth
+	parens  int  // parenthesis nesting depth
+	newline bool // the next token starts a new line
+	started bool // a token has been written
+
+	prev  token.Token // most recently written token
+	unary bool        // prev is a unary minus
+}
+
+func (f *formatter) token(tok token.Token) {
+	if tok.Type == token.Comment {
+		f.comment(tok)
+		return
+	}
+
+	if tok.Type == token.RightBrace {
+		f.depth--
+		// an empty block stays on one line
+		if f.prev.Type == token.LeftBrace {
+			f.newline = false
+		}
+	}
+	f.separate(tok)
+	f.buf.WriteString(tok.Lexeme)
+
+	switch tok.Type {
+	case token.LeftParen:
+		f.parens++
+	case token.RightParen:
+		f.parens--
+	case token.Semicolon:
+		// the clauses of a for loop share a line
+		f.newline = f.parens == 0
+	case token.LeftBrace:
+		f.depth++
+		f.newline = true
+	case token.RightBrace:
+		f.newline = true
+	}
+	f.unary = tok.Type == token.Minus && !endsOperand(f.prev)
+	f.prev = tok
+}
+
+// comment writes a comment. A comment that follows code on the same line
+// stays there, other comments are written on their own line.
+func (f *formatter) comment(tok token.Token) {
+	if f.started && tok.Pos.Line == f.prev.End.Line {
+		f.buf.WriteByte(' ')
+	} else {
+		f.newline = f.started
+		f.separate(tok)
+	}
+	f.buf.WriteString(strings.TrimRight(tok.Lexeme, " \t\r"))
+	f.newline = true
+	f.unary = false
+	f.prev = tok
+}
+
+// separate writes the whitespace between the previous token and tok
+func (f *formatter) separate(tok token.Token) {
+	elseBranch := tok.Type == token.Else && f.prev.Type == token.RightBrace
+
+	switch {
+	case !f.started:
+	case f.newline && !elseBranch:
+		f.buf.WriteByte('\n')
+		// keep a single blank line, but not at the start or end of a block
+		if tok.Pos.Line > f.prev.End.Line+1 && f.prev.Type != token.LeftBrace && tok.Type != token.RightBrace {
+			f.buf.WriteByte('\n')
+		}
+		f.buf.WriteString(strings.Repeat(indent, f.depth))
+	case f.space(tok):
+		f.buf.WriteByte(' ')
+	}
+	f.newline = false
+	f.started = true
+}
+
+// space reports whether tok is separated from the previous token on the same
+// line by a space
+func (f *formatter) space(tok token.Token) bool {
+	switch tok.Type {
+	case token.RightParen, token.Comma, token.Semicolon, token.Dot:
+		return false
+	case token.LeftParen:
+		// calls and parameter lists
+		if f.prev.Type == token.Identifier || f.prev.Type == token.RightParen {
+			return false
+		}
+	case token.RightBrace:
+		if f.prev.Type == token.LeftBrace {
+			return false
+		}
+	}
+
+	switch f.prev.Type {
+	case token.LeftParen, token.Dot, token.Bang:
+		return false
+	case token.Minus:
+		return !f.unary
+	}
+	return true
+}
+
+// endsOperand reports whether an operand can end with the token, which makes
+// a minus that follows it a binary operator
+func endsOperand(tok token.Token) bool {
+	switch tok.Type {
+	case token.Identifier, token.Number, token.String, token.True, token.False, token.Nil, token.This, token.RightParen:
+		return true
+	}
+	return false
+}
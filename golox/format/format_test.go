@@ -0,0 +1,129 @@
+package format
+
+import (
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/cornelmarck/crafting-interpreters/golox/ast"
+	"github.com/cornelmarck/crafting-interpreters/golox/token"
+	"github.com/cornelmarck/crafting-interpreters/testdata"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSource(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		Name     string
+		Src      string
+		Expected string
+	}{
+		{
+			Name:     "operators",
+			Src:      "print   -a+b*(c-  -1)==!d;",
+			Expected: "print -a + b * (c - -1) == !d;\n",
+		}, {
+			Name:     "statements on their own line",
+			Src:      "var a=1;print a;",
+			Expected: "var a = 1;\nprint a;\n",
+		}, {
+			Name:     "blocks",
+			Src:      "if(a){print 1;}else{ }\nwhile (true) {}",
+			Expected: "if (a) {\n  print 1;\n} else {}\nwhile (true) {}\n",
+		}, {
+			Name:     "for clauses",
+			Src:      "for(var i=0;i<3;i=i+1)print i;\nfor(;;){}",
+			Expected: "for (var i = 0; i < 3; i = i + 1) print i;\nfor (;;) {}\n",
+		}, {
+			Name: "functions and classes",
+			Src:  "fun add(a,b){return a+b;}\nclass B<A{init(x){super.init(x);this.y=add(x , 1);}}",
+			Expected: "fun add(a, b) {\n  return a + b;\n}\nclass B < A {\n  init(x) {\n    super.init(x);\n" +
+				"    this.y = add(x, 1);\n  }\n}\n",
+		}, {
+			Name:     "comments",
+			Src:      "// header\nprint 1;   // one\n{ // block\n  // inside\nprint 2; }",
+			Expected: "// header\nprint 1; // one\n{ // block\n  // inside\n  print 2;\n}\n",
+		}, {
+			Name:     "shebang",
+			Src:      "#!/usr/bin/env golox\nprint 1;",
+			Expected: "#!/usr/bin/env golox\nprint 1;\n",
+		}, {
+			Name:     "blank lines",
+			Src:      "print 1;\n\n\n\nprint 2;\n{\n\nprint 3;\n\n}\n",
+			Expected: "print 1;\n\nprint 2;\n{\n  print 3;\n}\n",
+		}, {
+			Name:     "empty",
+			Src:      "",
+			Expected: "",
+		},
+	} {
+		tc := tc
+
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			formatted, err := Source([]byte(tc.Src))
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expected, string(formatted))
+		})
+	}
+}
+
+func TestSourceSyntaxError(t *testing.T) {
+	t.Parallel()
+
+	_, err := Source([]byte("print 1"))
+	assert.EqualError(t, err, "[line 1] Error at end: Expect ';' after value.")
+}
+
+// TestSourceTestdata formats every valid script of the test suite. Formatting
+// must not change the syntax tree, and formatted source must stay as it is.
+func TestSourceTestdata(t *testing.T) {
+	t.Parallel()
+
+	err := fs.WalkDir(testdata.TestCases, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		src, err := fs.ReadFile(testdata.TestCases, name)
+		if err != nil {
+			return err
+		}
+		expected, ok := printTree(src)
+		if !ok {
+			// scripts with syntax errors can not be formatted
+			return nil
+		}
+
+		formatted, err := Source(src)
+		require.NoError(t, err, name)
+		actual, _ := printTree(formatted)
+		assert.Equal(t, expected, actual, name)
+
+		again, err := Source(formatted)
+		require.NoError(t, err, name)
+		assert.Equal(t, string(formatted), string(again), name)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func printTree(src []byte) (string, bool) {
+	tokens, err := token.NewScanner(src).Scan()
+	if err != nil {
+		return "", false
+	}
+	statements, err := ast.NewParser(tokens).Parse()
+	if err != nil {
+		return "", false
+	}
+
+	var sb strings.Builder
+	for _, statement := range statements {
+		ast.Fprint(&sb, statement)
+	}
+	return sb.String(), true
+}
@@ -1,11 +1,16 @@
 module github.com/cornelmarck/crafting-interpreters/golox
 
-go 1.23
+go 1.23.0
 
-require github.com/stretchr/testify v1.9.0
+require (
+	github.com/cornelmarck/crafting-interpreters/testdata v0.0.0-00010101000000-000000000000
+	github.com/stretchr/testify v1.9.0
+)
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
+
+replace github.com/cornelmarck/crafting-interpreters/testdata => ../testdata
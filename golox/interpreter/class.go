@@ -0,0 +1,69 @@
+package interpreter
+
+// class is the runtime representation of a class declaration. Calling a class
+// creates a new instance and runs its initializer.
+type class struct {
+	name       string
+	superclass *class // nil if the class does not inherit
+	methods    map[string]*function
+}
+
+// findMethod looks up a method on the class, continuing up the chain of
+// superclasses if the class itself does not define it
+func (c *class) findMethod(name string) (*function, bool) {
+	for cls := c; cls != nil; cls = cls.superclass {
+		if method, ok := cls.methods[name]; ok {
+			return method, true
+		}
+	}
+	return nil, false
+}
+
+func (c *class) Arity() int {
+	if initializer, ok := c.findMethod("init"); ok {
+		return initializer.Arity()
+	}
+	return 0
+}
+
+func (c *class) Call(i *Interpreter, arguments []Value) (Value, error) {
+	inst := &instance{
+		class:  c,
+		fields: make(map[string]Value),
+	}
+	if initializer, ok := c.findMethod("init"); ok {
+		if _, err := initializer.bind(inst).Call(i, arguments); err != nil {
+			return Value{}, err
+		}
+	}
+	return Value{inst}, nil
+}
+
+func (c *class) String() string {
+	return c.name
+}
+
+type instance struct {
+	class  *class
+	fields map[string]Value
+}
+
+// get returns the field with the given name, or a method bound to the
+// instance. Fields shadow methods.
+func (in *instance) get(name string) (Value, bool) {
+	if value, ok := in.fields[name]; ok {
+		return value, true
+	}
+	if method, ok := in.class.findMethod(name); ok {
+		return Value{method.bind(in)}, true
+	}
+	return Value{}, false
+}
+
+func (in *instance) set(name string, value Value) {
+	in.fields[name] = value
+}
+
+func (in *instance) String() string {
+	return in.class.name + " instance"
+}
@@ -1,28 +1,64 @@
 package interpreter
 
-import "fmt"
-
-// envirionment contains the state of a scope
+// envirionment contains the state of a scope. Scopes are chained, so a lookup
+// that misses in the current scope continues in the enclosing one.
 type environment struct {
 	// optimization: use a more performant hashing algorithm
-	values map[string]any
+	values    map[string]Value
+	enclosing *environment // nil for the global scope
 }
 
-func newEnvironment() environment {
-	return environment{
-		values: make(map[string]any),
+func newEnvironment(enclosing *environment) *environment {
+	return &environment{
+		values:    make(map[string]Value),
+		enclosing: enclosing,
 	}
 }
 
-func (e *environment) get(name string) (any, error) {
-	value, ok := e.values[name]
-	if !ok {
-		// todo: custom error type
-		return nil, fmt.Errorf("variable not defined: %s", name)
+// get reads a variable from the nearest scope that declares it. It reports
+// false if no scope declares the variable.
+func (e *environment) get(name string) (Value, bool) {
+	for env := e; env != nil; env = env.enclosing {
+		if value, ok := env.values[name]; ok {
+			return value, true
+		}
 	}
-	return value, nil
+	return Value{}, false
 }
 
-func (e *environment) set(name string, value any) {
+// define declares a variable in the current scope, shadowing any variable
+// with the same name in the enclosing scopes
+func (e *environment) define(name string, value Value) {
 	e.values[name] = value
 }
+
+// assign updates an existing variable in the nearest scope that declares it.
+// It reports false if the variable was never declared.
+func (e *environment) assign(name string, value Value) bool {
+	for env := e; env != nil; env = env.enclosing {
+		if _, ok := env.values[name]; ok {
+			env.values[name] = value
+			return true
+		}
+	}
+	return false
+}
+
+// ancestor returns the scope the given number of hops up the chain
+func (e *environment) ancestor(distance int) *environment {
+	env := e
+	for range distance {
+		env = env.enclosing
+	}
+	return env
+}
+
+// getAt reads a variable from the scope the resolver bound it to
+func (e *environment) getAt(distance int, name string) (Value, bool) {
+	return e.ancestor(distance).get(name)
+}
+
+// assignAt updates a variable in the scope the resolver bound it to
+func (e *environment) assignAt(distance int, name string, value Value) bool {
+	return e.ancestor(distance).assign(name, value)
+}
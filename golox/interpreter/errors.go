@@ -0,0 +1,100 @@
+package interpreter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cornelmarck/crafting-interpreters/golox/token"
+)
+
+// ErrorKind classifies a runtime error
+type ErrorKind int
+
+const (
+	TypeError         ErrorKind = iota // a value has the wrong type for the operation
+	UndefinedVariable                  // a variable is read or assigned before it is declared
+	UndefinedProperty                  // an instance has no field or method with the name
+	ArityMismatch                      // a call passes the wrong number of arguments
+	StackOverflow                      // the call depth exceeds the limit
+	NativeError                        // a function implemented in Go fails
+	Canceled                           // the context of the execution is done
+)
+
+func (k ErrorKind) String() string {
+	switch k {
+	case TypeError:
+		return "type error"
+	case UndefinedVariable:
+		return "undefined variable"
+	case UndefinedProperty:
+		return "undefined property"
+	case ArityMismatch:
+		return "arity mismatch"
+	case StackOverflow:
+		return "stack overflow"
+	case NativeError:
+		return "native error"
+	case Canceled:
+		return "canceled"
+	default:
+		return fmt.Sprintf("ErrorKind(%d)", int(k))
+	}
+}
+
+// Frame is an active Lox function call
+type Frame struct {
+	Function string         // name of the called function
+	Call     token.Position // position of the call expression in the caller
+}
+
+// RuntimeError is an error raised while executing Lox code. Error formats it
+// like the reference implementation: the message followed by the line.
+type RuntimeError struct {
+	Kind ErrorKind
+	Pos  token.Position
+	Msg  string
+
+	// Trace holds the active calls when the error was raised, innermost first
+	Trace []Frame
+
+	// Err is the cause of a NativeError or Canceled error: the error the
+	// native returned or the error of the context
+	Err error
+}
+
+func (e *RuntimeError) Error() string {
+	return fmt.Sprintf("%s\n[line %d]", e.Msg, e.Pos.Line)
+}
+
+func (e *RuntimeError) Unwrap() error {
+	return e.Err
+}
+
+// StackTrace lists the location of the error followed by the call sites of
+// the active functions, one line per frame. Code outside of any function is
+// reported as 'script'.
+func (e *RuntimeError) StackTrace() string {
+	var sb strings.Builder
+	pos := e.Pos
+	for _, frame := range e.Trace {
+		fmt.Fprintf(&sb, "[line %d] in %s()\n", pos.Line, frame.Function)
+		pos = frame.Call
+	}
+	fmt.Fprintf(&sb, "[line %d] in script\n", pos.Line)
+	return sb.String()
+}
+
+// runtimeError creates an error at the position that captures the current
+// call stack
+func (i *Interpreter) runtimeError(kind ErrorKind, pos token.Position, format string, args ...any) *RuntimeError {
+	trace := make([]Frame, len(i.frames))
+	for idx, frame := range i.frames {
+		trace[len(i.frames)-1-idx] = frame
+	}
+	return &RuntimeError{
+		Kind:  kind,
+		Pos:   pos,
+		Msg:   fmt.Sprintf(format, args...),
+		Trace: trace,
+	}
+}
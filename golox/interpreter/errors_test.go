@@ -0,0 +1,79 @@
+package interpreter
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/cornelmarck/crafting-interpreters/golox/ast"
+	"github.com/cornelmarck/crafting-interpreters/golox/token"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRuntimeError(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name       string
+		code       string
+		kind       ErrorKind
+		err        string
+		stackTrace string
+	}{
+		{
+			name: "operands at top level",
+			code: "var a = 1;\nprint a - \"b\";",
+			kind: TypeError,
+			err:  "Operands must be numbers.\n[line 2]",
+			stackTrace: "" +
+				"[line 2] in script\n",
+		}, {
+			name: "undefined variable in nested call",
+			code: "fun inner() {\n  return missing;\n}\nfun outer() {\n  inner();\n}\nouter();",
+			kind: UndefinedVariable,
+			err:  "Undefined variable 'missing'.\n[line 2]",
+			stackTrace: "" +
+				"[line 2] in inner()\n" +
+				"[line 5] in outer()\n" +
+				"[line 7] in script\n",
+		}, {
+			name: "arity mismatch in initializer call",
+			code: "class Foo {\n  init(a) {}\n}\nFoo();",
+			kind: ArityMismatch,
+			err:  "Expected 1 arguments but got 0.\n[line 4]",
+			stackTrace: "" +
+				"[line 4] in script\n",
+		}, {
+			name: "undefined property in method",
+			code: "class Foo {\n  bar() {\n    return this.baz;\n  }\n}\nFoo().bar();",
+			kind: UndefinedProperty,
+			err:  "Undefined property 'baz'.\n[line 3]",
+			stackTrace: "" +
+				"[line 3] in bar()\n" +
+				"[line 6] in script\n",
+		},
+	} {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			tokens, err := token.NewScanner([]byte(tc.code)).Scan()
+			require.NoError(t, err)
+			statements, err := ast.NewParser(tokens).Parse()
+			require.NoError(t, err)
+
+			interpreter := New(io.Discard)
+			require.NoError(t, interpreter.Resolve(statements...))
+			err = interpreter.Interpret(statements...)
+
+			var runtimeErr *RuntimeError
+			require.True(t, errors.As(err, &runtimeErr), "expected a runtime error, got %v", err)
+			assert.Equal(t, tc.kind, runtimeErr.Kind)
+			assert.Equal(t, tc.err, runtimeErr.Error())
+			assert.Equal(t, tc.stackTrace, runtimeErr.StackTrace())
+		})
+	}
+}
@@ -8,131 +8,290 @@ import (
 	"github.com/cornelmarck/crafting-interpreters/golox/token"
 )
 
-func evaluateExpression(expr ast.Expression, env environment) (any, error) {
+func (i *Interpreter) evaluateExpression(expr ast.Expression, env *environment) (Value, error) {
 	switch node := expr.(type) {
 	case ast.BooleanExpression:
-		return node.Value, nil
+		return MakeBool(node.Value), nil
 	case ast.NilExpression:
-		return nil, nil
+		return Value{}, nil
 	case ast.NumberExpression:
-		return node.Value, nil
+		return MakeNumber(node.Value), nil
 	case ast.StringExpression:
-		return node.Value, nil
-	case ast.VariableExpression:
-		return env.get(node.Name)
+		return MakeString(node.Value), nil
+	case *ast.VariableExpression:
+		return i.lookupVariable(node.Name, node, node.Pos, env)
+	case *ast.AssignExpression:
+		return i.evaluateAssign(node, env)
 	case *ast.GroupingExpression:
-		return evaluateExpression(node.Expression, env)
+		return i.evaluateExpression(node.Expression, env)
 	case *ast.UrnaryExpression:
-		return evaluateUrnary(node, env)
+		return i.evaluateUrnary(node, env)
 	case *ast.BinaryExpression:
-		return evaluateBinary(node, env)
+		return i.evaluateBinary(node, env)
+	case *ast.LogicalExpression:
+		return i.evaluateLogical(node, env)
+	case *ast.CallExpression:
+		return i.evaluateCall(node, env)
+	case *ast.GetExpression:
+		return i.evaluateGet(node, env)
+	case *ast.SetExpression:
+		return i.evaluateSet(node, env)
+	case *ast.ThisExpression:
+		return i.lookupVariable("this", node, node.Pos, env)
+	case *ast.SuperExpression:
+		return i.evaluateSuper(node, env)
 	default:
-		return nil, fmt.Errorf("invalid expression: %d", node.Type())
+		return Value{}, fmt.Errorf("invalid expression: %d", node.Type())
 	}
 }
 
-func evaluateUrnary(node *ast.UrnaryExpression, env environment) (any, error) {
-	right, err := evaluateExpression(node.Right, env)
+func (i *Interpreter) evaluateAssign(node *ast.AssignExpression, env *environment) (Value, error) {
+	value, err := i.evaluateExpression(node.Value, env)
 	if err != nil {
-		return nil, err
+		return Value{}, err
+	}
+
+	var ok bool
+	if distance, resolved := i.locals[node]; resolved {
+		ok = env.assignAt(distance, node.Name, value)
+	} else {
+		ok = i.globals.assign(node.Name, value)
+	}
+	if !ok {
+		return Value{}, i.runtimeError(UndefinedVariable, node.Pos, "Undefined variable '%s'.", node.Name)
+	}
+	return value, nil
+}
+
+// lookupVariable reads a variable from the scope it was resolved to
+func (i *Interpreter) lookupVariable(name string, node ast.Expression, pos token.Position, env *environment) (Value, error) {
+	var value Value
+	var ok bool
+	if distance, resolved := i.locals[node]; resolved {
+		value, ok = env.getAt(distance, name)
+	} else {
+		value, ok = i.globals.get(name)
+	}
+	if !ok {
+		return Value{}, i.runtimeError(UndefinedVariable, pos, "Undefined variable '%s'.", name)
+	}
+	return value, nil
+}
+
+func (i *Interpreter) evaluateUrnary(node *ast.UrnaryExpression, env *environment) (Value, error) {
+	right, err := i.evaluateExpression(node.Right, env)
+	if err != nil {
+		return Value{}, err
 	}
 
 	switch node.Operator {
 	case token.Bang:
-		return !isTruthy(right), nil
+		return MakeBool(!right.Truthy()), nil
 	case token.Minus:
-		v, ok := castUrnaryOperand[float64](right)
-		if !ok {
-			return nil, errors.New("invalid operand")
+		v, err := i.checkNumber(node.Pos, right)
+		if err != nil {
+			return Value{}, err
 		}
-		return -v, nil
+		return MakeNumber(-v), nil
 	}
 
-	return nil, errors.New("unknown urnary operator")
+	return Value{}, errors.New("unknown urnary operator")
 }
 
-func evaluateBinary(node *ast.BinaryExpression, env environment) (any, error) {
-	left, err := evaluateExpression(node.Left, env)
+func (i *Interpreter) evaluateBinary(node *ast.BinaryExpression, env *environment) (Value, error) {
+	left, err := i.evaluateExpression(node.Left, env)
 	if err != nil {
-		return nil, err
+		return Value{}, err
 	}
-	right, err := evaluateExpression(node.Right, env)
+	right, err := i.evaluateExpression(node.Right, env)
 	if err != nil {
-		return nil, err
+		return Value{}, err
 	}
 
 	// Equality check does not have type restrictions
 	switch node.Operator {
 	case token.EqualEqual:
-		return left == right, nil
+		return MakeBool(left.Equal(right)), nil
 	case token.BangEqual:
-		return left != right, nil
+		return MakeBool(!left.Equal(right)), nil
 	}
 
 	// Plus is a special case because of string concatenation
 	if node.Operator == token.Plus {
-		l, r, ok := castBinaryOperand[string](left, right)
-		if ok {
-			return l + r, nil
+		l, lok := left.AsString()
+		r, rok := right.AsString()
+		if lok && rok {
+			return MakeString(l + r), nil
+		}
+		if left.Kind() != NumberKind || right.Kind() != NumberKind {
+			return Value{}, i.runtimeError(TypeError, node.Pos, "Operands must be two numbers or two strings.")
 		}
 	}
 
 	// All remaining operands are numeric
-	l, r, ok := castBinaryOperand[float64](left, right)
-	if !ok {
-		return nil, errors.New("invalid operand")
+	l, r, err := i.checkNumbers(node.Pos, left, right)
+	if err != nil {
+		return Value{}, err
 	}
 
 	switch node.Operator {
 	// arithmetic
 	case token.Minus:
-		return l - r, nil
+		return MakeNumber(l - r), nil
 	case token.Plus:
-		return l + r, nil
+		return MakeNumber(l + r), nil
 	case token.Slash:
-		if r == .0 {
-			return nil, errors.New("divide by zero")
-		}
-		return l / r, nil
+		// dividing by zero results in an infinity, or NaN for zero divided by zero
+		return MakeNumber(l / r), nil
 	case token.Star:
-		return l * r, nil
+		return MakeNumber(l * r), nil
 	// comparison
 	case token.Greater:
-		return l > r, nil
+		return MakeBool(l > r), nil
 	case token.GreaterEqual:
-		return l >= r, nil
+		return MakeBool(l >= r), nil
 	case token.Less:
-		return l < r, nil
+		return MakeBool(l < r), nil
 	case token.LessEqual:
-		return l <= r, nil
+		return MakeBool(l <= r), nil
 	}
 
-	return nil, fmt.Errorf("invalid binary operator: %v", node.Operator.String())
+	return Value{}, fmt.Errorf("invalid binary operator: %v", node.Operator.String())
 }
 
-func isTruthy(value any) bool {
-	if value == nil {
-		return false
+// evaluateLogical only evaluates the right operand if the left operand does
+// not decide the result. The deciding operand itself is returned, not a bool.
+func (i *Interpreter) evaluateLogical(node *ast.LogicalExpression, env *environment) (Value, error) {
+	left, err := i.evaluateExpression(node.Left, env)
+	if err != nil {
+		return Value{}, err
 	}
-	if v, ok := value.(bool); ok {
-		return v
+
+	switch node.Operator {
+	case token.Or:
+		if left.Truthy() {
+			return left, nil
+		}
+	case token.And:
+		if !left.Truthy() {
+			return left, nil
+		}
+	default:
+		return Value{}, fmt.Errorf("invalid logical operator: %v", node.Operator.String())
 	}
-	return true
+	return i.evaluateExpression(node.Right, env)
 }
 
-func castUrnaryOperand[V any](value any) (v V, ok bool) {
-	v, ok = value.(V)
-	return
+func (i *Interpreter) evaluateCall(node *ast.CallExpression, env *environment) (Value, error) {
+	callee, err := i.evaluateExpression(node.Callee, env)
+	if err != nil {
+		return Value{}, err
+	}
+
+	arguments := make([]Value, 0, len(node.Arguments))
+	for _, argument := range node.Arguments {
+		value, err := i.evaluateExpression(argument, env)
+		if err != nil {
+			return Value{}, err
+		}
+		arguments = append(arguments, value)
+	}
+	return i.call(node.Pos, callee, arguments)
 }
 
-func castBinaryOperand[V any](a any, b any) (va V, vb V, ok bool) {
-	va, ok = a.(V)
+// call calls the callee with the arguments as a call at pos. It checks the
+// callee and the arguments, and keeps the frame of the call on the stack
+// while the callee runs.
+func (i *Interpreter) call(pos token.Position, callee Value, arguments []Value) (Value, error) {
+	fn, ok := callee.AsCallable()
 	if !ok {
-		return
+		return Value{}, i.runtimeError(TypeError, pos, "Can only call functions and classes.")
+	}
+	if len(arguments) != fn.Arity() {
+		return Value{}, i.runtimeError(ArityMismatch, pos, "Expected %d arguments but got %d.", fn.Arity(), len(arguments))
 	}
-	vb, ok = b.(V)
+
+	if len(i.frames) >= maxCallDepth {
+		return Value{}, i.runtimeError(StackOverflow, pos, "Stack overflow.")
+	}
+	if err := i.checkContext(pos); err != nil {
+		return Value{}, err
+	}
+	i.frames = append(i.frames, Frame{Function: callableName(fn), Call: pos})
+	defer func() { i.frames = i.frames[:len(i.frames)-1] }()
+
+	value, err := fn.Call(i, arguments)
+	if err != nil {
+		// errors of Go callables are raised in Lox at the call
+		var runtimeErr *RuntimeError
+		if !errors.As(err, &runtimeErr) {
+			runtimeErr = i.runtimeError(NativeError, pos, "%s", err)
+			runtimeErr.Err = err
+			return Value{}, runtimeErr
+		}
+		return Value{}, err
+	}
+	return value, nil
+}
+
+func (i *Interpreter) evaluateGet(node *ast.GetExpression, env *environment) (Value, error) {
+	object, err := i.evaluateExpression(node.Object, env)
+	if err != nil {
+		return Value{}, err
+	}
+
+	inst, ok := object.v.(*instance)
+	if !ok {
+		return Value{}, i.runtimeError(TypeError, node.Pos, "Only instances have properties.")
+	}
+	value, ok := inst.get(node.Name)
+	if !ok {
+		return Value{}, i.runtimeError(UndefinedProperty, node.Pos, "Undefined property '%s'.", node.Name)
+	}
+	return value, nil
+}
+
+func (i *Interpreter) evaluateSet(node *ast.SetExpression, env *environment) (Value, error) {
+	object, err := i.evaluateExpression(node.Object, env)
+	if err != nil {
+		return Value{}, err
+	}
+
+	inst, ok := object.v.(*instance)
+	if !ok {
+		return Value{}, i.runtimeError(TypeError, node.Pos, "Only instances have fields.")
+	}
+
+	value, err := i.evaluateExpression(node.Value, env)
+	if err != nil {
+		return Value{}, err
+	}
+	inst.set(node.Name, value)
+	return value, nil
+}
+
+func (i *Interpreter) evaluateSuper(node *ast.SuperExpression, env *environment) (Value, error) {
+	// the resolver binds 'super' inside subclass methods only
+	distance, ok := i.locals[node]
+	if !ok {
+		return Value{}, i.runtimeError(UndefinedVariable, node.Pos, "Undefined variable 'super'.")
+	}
+	value, _ := env.getAt(distance, "super")
+	superclass, ok := value.v.(*class)
+	if !ok {
+		return Value{}, i.runtimeError(UndefinedVariable, node.Pos, "Undefined variable 'super'.")
+	}
+
+	// 'this' is bound in the scope directly inside the one that binds 'super'
+	value, _ = env.getAt(distance-1, "this")
+	inst, ok := value.v.(*instance)
+	if !ok {
+		return Value{}, i.runtimeError(UndefinedVariable, node.Pos, "Undefined variable 'this'.")
+	}
+
+	method, ok := superclass.findMethod(node.Method)
 	if !ok {
-		return
+		return Value{}, i.runtimeError(UndefinedProperty, node.Pos, "Undefined property '%s'.", node.Method)
 	}
-	return
+	return Value{method.bind(inst)}, nil
 }
@@ -1,6 +1,8 @@
 package interpreter
 
 import (
+	"io"
+	"math"
 	"testing"
 
 	"github.com/cornelmarck/crafting-interpreters/golox/ast"
@@ -54,15 +56,35 @@ func TestEvaluateExpression(t *testing.T) {
 				Left:     ast.StringExpression{Value: "hello"},
 				Right:    ast.NumberExpression{Value: float64(3)},
 			},
-			Error: "invalid operand",
+			Error: "Operands must be two numbers or two strings.",
 		}, {
 			Name: "divide by zero",
 			Expression: &ast.BinaryExpression{
 				Operator: token.Slash,
-				Left:     ast.NumberExpression{Value: float64(0)},
+				Left:     ast.NumberExpression{Value: float64(1)},
 				Right:    ast.NumberExpression{Value: float64(0.0)},
 			},
-			Error: "divide by zero",
+			Expected: math.Inf(1),
+		}, {
+			Name: "or returns deciding operand; nil or 'ok'",
+			Expression: &ast.LogicalExpression{
+				Operator: token.Or,
+				Left:     ast.NilExpression{},
+				Right:    ast.StringExpression{Value: "ok"},
+			},
+			Expected: "ok",
+		}, {
+			Name: "and short-circuits; false and undefined",
+			Expression: &ast.LogicalExpression{
+				Operator: token.And,
+				Left:     ast.BooleanExpression{Value: false},
+				Right:    &ast.VariableExpression{Name: "undefined"},
+			},
+			Expected: false,
+		}, {
+			Name:       "unresolved super",
+			Expression: &ast.SuperExpression{Method: "x"},
+			Error:      "Undefined variable 'super'.",
 		},
 	} {
 		tc := tc
@@ -70,10 +92,10 @@ func TestEvaluateExpression(t *testing.T) {
 		t.Run(tc.Name, func(t *testing.T) {
 			t.Parallel()
 
-			res, err := evaluateExpression(tc.Expression, environment{})
+			res, err := New(io.Discard).evaluateExpression(tc.Expression, newEnvironment(nil))
 			if tc.Error == "" {
 				assert.NoError(t, err)
-				assert.Equal(t, tc.Expected, res)
+				assert.Equal(t, tc.Expected, res.v)
 			} else {
 				assert.ErrorContains(t, err, tc.Error)
 			}
@@ -0,0 +1,96 @@
+package interpreter
+
+import (
+	"fmt"
+
+	"github.com/cornelmarck/crafting-interpreters/golox/ast"
+)
+
+// maxCallDepth limits recursion so a runaway script fails with an error
+// instead of exhausting the Go stack
+const maxCallDepth = 1024
+
+// Callable is implemented by every value that can be called from Lox code.
+// The interpreter checks the number of arguments against Arity before it
+// calls Call. Implementations must be comparable, see MakeCallable.
+type Callable interface {
+	Arity() int
+	Call(i *Interpreter, arguments []Value) (Value, error)
+}
+
+// function is a user-defined Lox function together with the scope it was
+// declared in
+type function struct {
+	declaration   ast.FunctionDeclaration
+	closure       *environment
+	locals        map[ast.Expression]int // resolved variables of the declaring code
+	isInitializer bool                   // initializers always return 'this'
+}
+
+// bind returns a copy of the method with 'this' bound to the instance
+func (f *function) bind(in *instance) *function {
+	env := newEnvironment(f.closure)
+	env.define("this", Value{in})
+	return &function{
+		declaration:   f.declaration,
+		closure:       env,
+		locals:        f.locals,
+		isInitializer: f.isInitializer,
+	}
+}
+
+func (f *function) Arity() int {
+	return len(f.declaration.Params)
+}
+
+func (f *function) Call(i *Interpreter, arguments []Value) (Value, error) {
+	defer i.setLocals(f.locals)()
+
+	env := newEnvironment(f.closure)
+	for idx, param := range f.declaration.Params {
+		env.define(param.Name, arguments[idx])
+	}
+
+	err := i.executeBlock(f.declaration.Body, env)
+	ret, isReturn := err.(*returnValue)
+	if err != nil && !isReturn {
+		return Value{}, err
+	}
+
+	if f.isInitializer {
+		this, _ := f.closure.get("this")
+		return this, nil
+	}
+	if isReturn {
+		return ret.value, nil
+	}
+	return Value{}, nil
+}
+
+// callableName is the name of the callable in a stack trace
+func callableName(fn Callable) string {
+	switch fn := fn.(type) {
+	case *function:
+		return fn.declaration.Name
+	case *class:
+		return fn.name
+	case *native:
+		return fn.name
+	default:
+		return fmt.Sprintf("%v", fn)
+	}
+}
+
+func (f *function) String() string {
+	return fmt.Sprintf("<fn %s>", f.declaration.Name)
+}
+
+// returnValue unwinds the statements of a function body. It travels up the
+// call chain as an error until the enclosing function call catches it.
+type returnValue struct {
+	value Value
+}
+
+func (r *returnValue) Error() string {
+	return "return statement outside of function"
+}
@@ -1,58 +1,376 @@
 package interpreter
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"reflect"
+	"slices"
+	"strings"
 
 	"github.com/cornelmarck/crafting-interpreters/golox/ast"
+	"github.com/cornelmarck/crafting-interpreters/golox/token"
 )
 
 type Interpreter struct {
-	env     environment
-	printer io.Writer
+	globals *environment
+
+	stdin  io.Reader
+	stdout io.Writer // print writes here
+	stderr io.Writer
+
+	// locals maps each resolved variable reference of the running code to
+	// the number of scopes between the reference and its declaration.
+	// Unresolved references are looked up in the global scope. Every call to
+	// Interpret or Eval has its own locals, functions keep the locals of the
+	// code that declared them.
+	locals map[ast.Expression]int
+
+	frames []Frame // active Lox function calls, outermost first
+
+	ctx context.Context // context of the running script, nil if it has none
+}
+
+// Option configures an Interpreter
+type Option func(*Interpreter)
+
+// WithStdin sets the input natives read from. The default is empty.
+func WithStdin(r io.Reader) Option {
+	return func(i *Interpreter) {
+		i.stdin = r
+	}
 }
 
-func New(printer io.Writer) *Interpreter {
-	return &Interpreter{
-		env:     newEnvironment(),
-		printer: printer,
+// WithStderr sets the writer natives report diagnostics to. The default
+// discards them.
+func WithStderr(w io.Writer) Option {
+	return func(i *Interpreter) {
+		i.stderr = w
 	}
 }
 
+// New creates an interpreter that writes the output of print statements to
+// stdout
+func New(stdout io.Writer, options ...Option) *Interpreter {
+	i := &Interpreter{
+		globals: newEnvironment(nil),
+		stdin:   strings.NewReader(""),
+		stdout:  stdout,
+		stderr:  io.Discard,
+	}
+	for _, option := range options {
+		option(i)
+	}
+	i.defineNatives()
+	return i
+}
+
+// Stdin returns the input of the interpreter, for use by natives
+func (i *Interpreter) Stdin() io.Reader {
+	return i.stdin
+}
+
+// Stdout returns the writer print statements write to
+func (i *Interpreter) Stdout() io.Writer {
+	return i.stdout
+}
+
+// Stderr returns the writer for diagnostics, for use by natives
+func (i *Interpreter) Stderr() io.Writer {
+	return i.stderr
+}
+
+// Globals returns a copy of the variables defined in the global scope
+func (i *Interpreter) Globals() map[string]Value {
+	return maps.Clone(i.globals.values)
+}
+
+// GetGlobal returns the value of a global variable. It reports false if the
+// variable is not defined.
+func (i *Interpreter) GetGlobal(name string) (Value, bool) {
+	return i.globals.get(name)
+}
+
+// SetGlobal defines a global variable, or replaces its value if it is
+// already defined
+func (i *Interpreter) SetGlobal(name string, value Value) {
+	i.globals.define(name, value)
+}
+
+// Interpret resolves and executes the statements. Resolution errors are
+// returned before anything runs. Called by a native, it runs in the context
+// of the calling script.
 func (i *Interpreter) Interpret(statements ...ast.Statement) error {
+	locals, err := resolve(statements)
+	if err != nil {
+		return err
+	}
+	defer i.setLocals(locals)()
+	return i.executeTopLevel(statements)
+}
+
+// setLocals makes the locals those of the running code and returns a function
+// that restores the previous ones
+func (i *Interpreter) setLocals(locals map[ast.Expression]int) func() {
+	prev := i.locals
+	i.locals = locals
+	return func() { i.locals = prev }
+}
+
+// executeTopLevel executes the statements in the global scope
+func (i *Interpreter) executeTopLevel(statements []ast.Statement) error {
 	for _, s := range statements {
-		if err := i.execute(s); err != nil {
+		if err := i.execute(s, i.globals); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func (i *Interpreter) execute(statement ast.Statement) error {
+// InterpretContext executes the statements until they complete or the
+// context is done. The context is checked before every loop iteration and
+// function call, cancellation returns a *RuntimeError of kind Canceled that
+// wraps the error of the context.
+func (i *Interpreter) InterpretContext(ctx context.Context, statements ...ast.Statement) error {
+	prev := i.ctx
+	i.ctx = ctx
+	defer func() { i.ctx = prev }()
+	return i.Interpret(statements...)
+}
+
+// EvalContext is Eval, stopped like InterpretContext once the context is
+// done
+func (i *Interpreter) EvalContext(ctx context.Context, src string) (Value, error) {
+	prev := i.ctx
+	i.ctx = ctx
+	defer func() { i.ctx = prev }()
+	return i.Eval(src)
+}
+
+// checkContext returns a Canceled error at the position if the context of
+// the running script is done
+func (i *Interpreter) checkContext(pos token.Position) error {
+	if i.ctx == nil {
+		return nil
+	}
+	if err := i.ctx.Err(); err != nil {
+		runtimeErr := i.runtimeError(Canceled, pos, "Execution canceled: %v.", err)
+		runtimeErr.Err = err
+		return runtimeErr
+	}
+	return nil
+}
+
+// Eval scans, parses, resolves and runs the source in the global scope. The
+// source is a list of statements, the semicolon that ends the last statement
+// is optional. Eval returns the value of the final statement if it is an
+// expression, and nil otherwise.
+//
+// Syntax and resolution errors are returned before anything runs, they are
+// token.ErrorLists joined by errors.Join.
+func (i *Interpreter) Eval(src string) (Value, error) {
+	tokens, scanErr := token.NewScanner([]byte(src)).Scan()
+	statements, parseErr := ast.NewParser(tokens).Parse()
+	if scanErr == nil && parseErr != nil {
+		if s, err := ast.NewParser(terminate(tokens)).Parse(); err == nil {
+			statements, parseErr = s, nil
+		}
+	}
+	if err := errors.Join(scanErr, parseErr); err != nil {
+		return Value{}, err
+	}
+	locals, err := resolve(statements)
+	if err != nil {
+		return Value{}, err
+	}
+	defer i.setLocals(locals)()
+
+	var last ast.Expression
+	if n := len(statements); n > 0 {
+		if statement, ok := statements[n-1].(ast.ExpressionStatement); ok {
+			statements, last = statements[:n-1], statement.Expression
+		}
+	}
+	if err := i.executeTopLevel(statements); err != nil {
+		return Value{}, err
+	}
+	if last == nil {
+		return Value{}, nil
+	}
+	return i.evaluateExpression(last, i.globals)
+}
+
+// terminate returns the tokens with a semicolon inserted before the final
+// EOF token
+func terminate(tokens []token.Token) []token.Token {
+	eof := tokens[len(tokens)-1]
+	semicolon := token.Token{Type: token.Semicolon, Pos: eof.Pos, End: eof.Pos}
+	return append(slices.Clip(tokens[:len(tokens)-1]), semicolon, eof)
+}
+
+// Call calls a Lox function, class or native with the arguments. It fails
+// like a call in Lox, every error is a *RuntimeError. Called by a native, it
+// runs in the context of the calling script and the call is placed at the
+// call of the native, a call from Go has no position.
+func (i *Interpreter) Call(fn Value, arguments ...Value) (Value, error) {
+	var pos token.Position
+	if n := len(i.frames); n > 0 {
+		pos = i.frames[n-1].Call
+	}
+	return i.call(pos, fn, arguments)
+}
+
+// CallContext is Call, stopped like InterpretContext once the context is
+// done
+func (i *Interpreter) CallContext(ctx context.Context, fn Value, arguments ...Value) (Value, error) {
+	prev := i.ctx
+	i.ctx = ctx
+	defer func() { i.ctx = prev }()
+	return i.Call(fn, arguments...)
+}
+
+func (i *Interpreter) execute(statement ast.Statement, env *environment) error {
 	switch node := statement.(type) {
 	case ast.PrintStatement:
-		return i.executePrint(node)
+		return i.executePrint(node, env)
 	case ast.ExpressionStatement:
-		_, err := evaluateExpression(node.Expression, i.env)
+		_, err := i.evaluateExpression(node.Expression, env)
 		return err
 	case ast.VariableDeclaration:
-		value, err := evaluateExpression(node.Initializer, i.env)
-		if err != nil {
-			return err
-		}
-		i.env.set(node.Name, value)
+		return i.executeVariableDeclaration(node, env)
+	case ast.BlockStatement:
+		return i.executeBlock(node.Statements, newEnvironment(env))
+	case ast.IfStatement:
+		return i.executeIf(node, env)
+	case ast.WhileStatement:
+		return i.executeWhile(node, env)
+	case ast.FunctionDeclaration:
+		env.define(node.Name, Value{&function{declaration: node, closure: env, locals: i.locals}})
 		return nil
+	case ast.ReturnStatement:
+		return i.executeReturn(node, env)
+	case ast.ClassDeclaration:
+		return i.executeClass(node, env)
 	default:
 		return fmt.Errorf("unknown statement: %s", reflect.TypeOf(statement).String())
 	}
 }
 
-func (i *Interpreter) executePrint(node ast.PrintStatement) error {
-	value, err := evaluateExpression(node.Expression, i.env)
+func (i *Interpreter) executePrint(node ast.PrintStatement, env *environment) error {
+	value, err := i.evaluateExpression(node.Expression, env)
 	if err != nil {
 		return err
 	}
-	_, err = fmt.Fprintln(i.printer, value)
+	_, err = fmt.Fprintln(i.stdout, value)
 	return err
 }
+
+func (i *Interpreter) executeVariableDeclaration(node ast.VariableDeclaration, env *environment) error {
+	var value Value
+	if node.Initializer != nil {
+		var err error
+		value, err = i.evaluateExpression(node.Initializer, env)
+		if err != nil {
+			return err
+		}
+	}
+	env.define(node.Name, value)
+	return nil
+}
+
+// executeBlock runs the statements in the given scope, which is discarded
+// when the block exits
+func (i *Interpreter) executeBlock(statements []ast.Statement, env *environment) error {
+	for _, s := range statements {
+		if err := i.execute(s, env); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (i *Interpreter) executeIf(node ast.IfStatement, env *environment) error {
+	condition, err := i.evaluateExpression(node.Condition, env)
+	if err != nil {
+		return err
+	}
+
+	if condition.Truthy() {
+		return i.execute(node.Then, env)
+	} else if node.Else != nil {
+		return i.execute(node.Else, env)
+	}
+	return nil
+}
+
+func (i *Interpreter) executeWhile(node ast.WhileStatement, env *environment) error {
+	for {
+		condition, err := i.evaluateExpression(node.Condition, env)
+		if err != nil {
+			return err
+		}
+		if !condition.Truthy() {
+			return nil
+		}
+
+		if err := i.checkContext(node.Pos); err != nil {
+			return err
+		}
+		if err := i.execute(node.Body, env); err != nil {
+			return err
+		}
+	}
+}
+
+func (i *Interpreter) executeReturn(node ast.ReturnStatement, env *environment) error {
+	var value Value
+	if node.Value != nil {
+		var err error
+		value, err = i.evaluateExpression(node.Value, env)
+		if err != nil {
+			return err
+		}
+	}
+	return &returnValue{value: value}
+}
+
+func (i *Interpreter) executeClass(node ast.ClassDeclaration, env *environment) error {
+	var superclass *class
+	if node.Superclass != nil {
+		value, err := i.evaluateExpression(node.Superclass, env)
+		if err != nil {
+			return err
+		}
+		var ok bool
+		superclass, ok = value.v.(*class)
+		if !ok {
+			return i.runtimeError(TypeError, node.Superclass.Pos, "Superclass must be a class.")
+		}
+	}
+
+	// methods of a subclass close over a scope that binds 'super'
+	methodEnv := env
+	if superclass != nil {
+		methodEnv = newEnvironment(env)
+		methodEnv.define("super", Value{superclass})
+	}
+
+	methods := make(map[string]*function, len(node.Methods))
+	for _, method := range node.Methods {
+		methods[method.Name] = &function{
+			declaration:   method,
+			closure:       methodEnv,
+			locals:        i.locals,
+			isInitializer: method.Name == "init",
+		}
+	}
+
+	env.define(node.Name, Value{&class{
+		name:       node.Name,
+		superclass: superclass,
+		methods:    methods,
+	}})
+	return nil
+}
@@ -2,11 +2,18 @@ package interpreter
 
 import (
 	"bytes"
+	"context"
+	"fmt"
+	"io"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/cornelmarck/crafting-interpreters/golox/ast"
 	"github.com/cornelmarck/crafting-interpreters/golox/token"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var (
@@ -43,13 +50,299 @@ func TestInterpreter(t *testing.T) {
 				print x;
 			`,
 			expected: `1`,
+		}, {
+			name: "assign variable",
+			code: `
+				var a = "before";
+				var b = a = "after";
+				print a;
+				print b;
+			`,
+			expected: `
+				after
+				after
+			`,
+		}, {
+			name: "assign undefined variable",
+			code: `
+				unknown = "what";
+			`,
+			err: "Undefined variable 'unknown'.",
+		}, {
+			name: "shadow variable in block",
+			code: `
+				var a = "global";
+				{
+					var a = "shadow";
+					print a;
+				}
+				print a;
+			`,
+			expected: `
+				shadow
+				global
+			`,
+		}, {
+			name: "assign enclosing variable from block",
+			code: `
+				var a = "outer";
+				{
+					{
+						a = "inner";
+					}
+				}
+				print a;
+			`,
+			expected: `inner`,
+		}, {
+			name: "block variable out of scope",
+			code: `
+				{
+					var a = "local";
+				}
+				print a;
+			`,
+			err: "Undefined variable 'a'.",
+		}, {
+			name: "if else",
+			code: `
+				if (true) print "then"; else print "else";
+				if (nil) print "then"; else print "else";
+				if (0) print "zero is truthy";
+				if (false) if (true) print "inner"; else print "dangling";
+			`,
+			expected: `
+				then
+				else
+				zero is truthy
+			`,
+		}, {
+			name: "while loop",
+			code: `
+				var i = 0;
+				while (i < 3) {
+					print i;
+					i = i + 1;
+				}
+			`,
+			expected: `
+				0
+				1
+				2
+			`,
+		}, {
+			name: "for loop",
+			code: `
+				for (var i = 0; i < 3; i = i + 1) print i;
+				var j = 0;
+				for (; j < 2;) j = j + 1;
+				print j;
+			`,
+			expected: `
+				0
+				1
+				2
+				2
+			`,
+		}, {
+			name: "for loop variable is scoped to the loop",
+			code: `
+				for (var i = 0; i < 1; i = i + 1) {}
+				print i;
+			`,
+			err: "Undefined variable 'i'.",
+		}, {
+			name: "function call",
+			code: `
+				fun add(a, b) {
+					return a + b;
+				}
+				print add(1, 2);
+				print add;
+			`,
+			expected: `
+				3
+				<fn add>
+			`,
+		}, {
+			name: "return from loop",
+			code: `
+				fun first() {
+					for (var i = 1;; i = i + 1) {
+						if (i > 2) return i;
+					}
+				}
+				print first();
+			`,
+			expected: `3`,
+		}, {
+			name: "recursion",
+			code: `
+				fun fib(n) {
+					if (n < 2) return n;
+					return fib(n - 1) + fib(n - 2);
+				}
+				print fib(10);
+			`,
+			expected: `55`,
+		}, {
+			name: "closure counter",
+			code: `
+				fun makeCounter() {
+					var i = 0;
+					fun count() {
+						i = i + 1;
+						return i;
+					}
+					return count;
+				}
+				var counter = makeCounter();
+				counter();
+				print counter();
+			`,
+			expected: `2`,
+		}, {
+			name: "wrong number of arguments",
+			code: `
+				fun f(a, b) {}
+				f(1);
+			`,
+			err: "Expected 2 arguments but got 1.",
+		}, {
+			name: "call non-function",
+			code: `
+				"str"();
+			`,
+			err: "Can only call functions and classes.",
+		}, {
+			name: "stack overflow",
+			code: `
+				fun f() { f(); }
+				f();
+			`,
+			err: "Stack overflow.",
+		}, {
+			name: "class instance with fields",
+			code: `
+				class Point {}
+				var p = Point();
+				p.x = 1;
+				p.y = p.x + 1;
+				print Point;
+				print p;
+				print p.y;
+			`,
+			expected: `
+				Point
+				Point instance
+				2
+			`,
+		}, {
+			name: "bound method keeps receiver",
+			code: `
+				class Greeter {
+					init(name) {
+						this.name = name;
+					}
+					greet() {
+						print "hello " + this.name;
+					}
+				}
+				var greet = Greeter("lox").greet;
+				greet();
+			`,
+			expected: `hello lox`,
+		}, {
+			name: "initializer returns this",
+			code: `
+				class Foo {
+					init() {
+						this.count = 0;
+						return;
+					}
+				}
+				var foo = Foo();
+				foo.count = 1;
+				print foo.init() == foo;
+				print foo.count;
+			`,
+			expected: `
+				true
+				0
+			`,
+		}, {
+			name: "undefined property",
+			code: `
+				class Foo {}
+				Foo().bar;
+			`,
+			err: "Undefined property 'bar'.",
+		}, {
+			name: "get property on non-instance",
+			code: `
+				"str".length;
+			`,
+			err: "Only instances have properties.",
+		}, {
+			name: "inherit and call super",
+			code: `
+				class A {
+					method() {
+						print "A.method";
+					}
+					other() {
+						print "A.other";
+					}
+				}
+				class B < A {
+					method() {
+						print "B.method";
+						super.method();
+					}
+				}
+				class C < B {}
+				C().method();
+				C().other();
+			`,
+			expected: `
+				B.method
+				A.method
+				A.other
+			`,
+		}, {
+			name: "inherit from non-class",
+			code: `
+				var NotAClass = "so not a class";
+				class Foo < NotAClass {}
+			`,
+			err: "Superclass must be a class.",
+		}, {
+			name: "closure binds to the scope it was declared in",
+			code: `
+				var a = "global";
+				{
+					fun show() {
+						print a;
+					}
+					show();
+					var a = "local";
+					show();
+				}
+			`,
+			expected: `
+				global
+				global
+			`,
 		},
 	} {
 		tc := tc
 
 		t.Run(tc.name, func(t *testing.T) {
 			scanner := token.NewScanner([]byte(tc.code))
-			tokens := scanner.Scan()
+			tokens, err := scanner.Scan()
+			if err != nil {
+				t.Fatalf("error scanning code: %v", err)
+			}
 
 			parser := ast.NewParser(tokens)
 			statements, err := parser.Parse()
@@ -59,6 +352,9 @@ func TestInterpreter(t *testing.T) {
 
 			var buf bytes.Buffer
 			interpreter := New(&buf)
+			if err := interpreter.Resolve(statements...); err != nil {
+				t.Fatalf("error resolving code: %v", err)
+			}
 
 			err = interpreter.Interpret(statements...)
 			if tc.err == "" && err != nil {
@@ -77,9 +373,360 @@ func TestInterpreter(t *testing.T) {
 
 func parseExpectedStdOut(s string) string {
 	s = strings.TrimSpace(s)
+	if s == "" {
+		return ""
+	}
 	lines := strings.Split(s, "\n")
 	for i, line := range lines {
 		lines[i] = strings.TrimLeft(line, indentString)
 	}
 	return strings.Join(lines, "\n") + "\n"
 }
+
+func TestEval(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	interpreter := New(&buf)
+
+	for _, tc := range []struct {
+		src      string
+		expected Value
+		output   string
+		err      string
+	}{
+		{src: "var a = 1;"},
+		{src: "a + 1", expected: MakeNumber(2)},
+		{src: "a = a + 1;", expected: MakeNumber(2)},
+		{src: "print a; \"done\";", expected: MakeString("done"), output: "2\n"},
+		{src: "fun f() {}"},
+		{src: "f()"},
+		{src: "var s = 1; s", expected: MakeNumber(1)},
+		{src: "print s", output: "1\n"},
+		{src: "1 +", err: "[line 1] Error at end: Expect expression."},
+		{src: "-\"a\"", err: "Operand must be a number.\n[line 1]"},
+	} {
+		buf.Reset()
+		value, err := interpreter.Eval(tc.src)
+		if tc.err != "" {
+			assert.EqualError(t, err, tc.err, tc.src)
+			continue
+		}
+		require.NoError(t, err, tc.src)
+		assert.Equal(t, tc.expected, value, tc.src)
+		assert.Equal(t, tc.output, buf.String(), tc.src)
+	}
+
+	value, err := interpreter.Eval("class P { init(x) { this.x = x; } } P(3)")
+	require.NoError(t, err)
+	x, _ := value.Field("x")
+	assert.Equal(t, MakeNumber(3), x)
+}
+
+func TestEvalLocals(t *testing.T) {
+	t.Parallel()
+
+	interpreter := New(io.Discard)
+	_, err := interpreter.Eval(`
+		fun counter() {
+			var count = 0;
+			fun next() { count = count + 1; return count; }
+			return next;
+		}
+		var next = counter();
+	`)
+	require.NoError(t, err)
+
+	for n := 1; n <= 3; n++ {
+		// every call runs with the locals of the code that declared next
+		value, err := interpreter.Eval("{ var local = next(); local; }")
+		require.NoError(t, err)
+		assert.Equal(t, Value{}, value)
+		value, err = interpreter.Eval("next()")
+		require.NoError(t, err)
+		assert.Equal(t, MakeNumber(float64(2*n)), value)
+	}
+	assert.Empty(t, interpreter.locals, "locals are released once the code ran")
+}
+
+func TestGlobals(t *testing.T) {
+	t.Parallel()
+
+	interpreter := New(io.Discard)
+	interpreter.SetGlobal("limit", MakeNumber(3))
+
+	_, err := interpreter.Eval("var doubled = limit * 2;")
+	require.NoError(t, err)
+
+	doubled, ok := interpreter.GetGlobal("doubled")
+	assert.True(t, ok)
+	assert.Equal(t, MakeNumber(6), doubled)
+
+	_, ok = interpreter.GetGlobal("missing")
+	assert.False(t, ok)
+}
+
+func TestCall(t *testing.T) {
+	t.Parallel()
+
+	interpreter := New(io.Discard)
+	_, err := interpreter.Eval(`
+		fun greet(name) { return "hello " + name; }
+		class Point { init(x) { this.x = x; } }
+		fun fail() { return -nil; }
+	`)
+	require.NoError(t, err)
+	greet, _ := interpreter.GetGlobal("greet")
+	point, _ := interpreter.GetGlobal("Point")
+	fail, _ := interpreter.GetGlobal("fail")
+
+	value, err := interpreter.Call(greet, MakeString("lox"))
+	require.NoError(t, err)
+	assert.Equal(t, MakeString("hello lox"), value)
+
+	value, err = interpreter.Call(point, MakeNumber(1))
+	require.NoError(t, err)
+	assert.Equal(t, InstanceKind, value.Kind())
+	name, _ := value.ClassName()
+	assert.Equal(t, "Point", name)
+	x, ok := value.Field("x")
+	assert.True(t, ok)
+	assert.Equal(t, MakeNumber(1), x)
+
+	var runtimeErr *RuntimeError
+	_, err = interpreter.Call(greet)
+	require.ErrorAs(t, err, &runtimeErr)
+	assert.Equal(t, ArityMismatch, runtimeErr.Kind)
+	assert.Equal(t, "Expected 1 arguments but got 0.", runtimeErr.Msg)
+
+	_, err = interpreter.Call(MakeNumber(1))
+	require.ErrorAs(t, err, &runtimeErr)
+	assert.Equal(t, TypeError, runtimeErr.Kind)
+	assert.Equal(t, "Can only call functions and classes.", runtimeErr.Msg)
+
+	_, err = interpreter.Call(fail)
+	require.ErrorAs(t, err, &runtimeErr)
+	assert.Equal(t, TypeError, runtimeErr.Kind)
+	assert.Equal(t, "[line 4] in fail()\n[line 0] in script\n", runtimeErr.StackTrace())
+}
+
+func TestCallStackTrace(t *testing.T) {
+	t.Parallel()
+
+	interpreter := New(io.Discard)
+	interpreter.DefineNative("apply", 1, func(arguments []Value) (Value, error) {
+		return interpreter.Call(arguments[0])
+	})
+
+	_, err := interpreter.Eval("fun inner() {\n  return -nil;\n}\nfun outer() {\n  apply(inner);\n}\nouter();")
+	var runtimeErr *RuntimeError
+	require.ErrorAs(t, err, &runtimeErr)
+	assert.Equal(t, TypeError, runtimeErr.Kind)
+	assert.Equal(t, ""+
+		"[line 2] in inner()\n"+
+		"[line 5] in apply()\n"+
+		"[line 5] in outer()\n"+
+		"[line 7] in script\n", runtimeErr.StackTrace())
+}
+
+func TestOptions(t *testing.T) {
+	t.Parallel()
+
+	var stdout, stderr bytes.Buffer
+	interpreter := New(&stdout, WithStdin(strings.NewReader("input\n")), WithStderr(&stderr))
+	interpreter.DefineNative("echo", 0, func([]Value) (Value, error) {
+		line, err := io.ReadAll(interpreter.Stdin())
+		fmt.Fprint(interpreter.Stderr(), "read ", len(line), " bytes\n")
+		return MakeString(strings.TrimSpace(string(line))), err
+	})
+
+	_, err := interpreter.Eval("print echo();")
+	require.NoError(t, err)
+	assert.Equal(t, "input\n", stdout.String())
+	assert.Equal(t, "read 6 bytes\n", stderr.String())
+	assert.Equal(t, &stdout, interpreter.Stdout())
+
+	// the defaults provide no input and discard diagnostics
+	interpreter = New(io.Discard)
+	line, err := io.ReadAll(interpreter.Stdin())
+	require.NoError(t, err)
+	assert.Empty(t, line)
+	assert.Equal(t, io.Discard, interpreter.Stderr())
+}
+
+func TestInterpretContext(t *testing.T) {
+	t.Parallel()
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for _, tc := range []struct {
+		name     string
+		code     string
+		ctx      func() (context.Context, context.CancelFunc)
+		output   string
+		expected error
+		err      string
+	}{
+		{
+			name: "timeout in loop",
+			code: "var i = 0;\nwhile (true) {\n  i = i + 1;\n}",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 10*time.Millisecond)
+			},
+			expected: context.DeadlineExceeded,
+			err:      "Execution canceled: context deadline exceeded.\n[line 2]",
+		}, {
+			name: "canceled before call",
+			code: "fun f() {}\n\nf();",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return canceled, func() {}
+			},
+			expected: context.Canceled,
+			err:      "Execution canceled: context canceled.\n[line 3]",
+		}, {
+			name: "for loop",
+			code: "for (;;) {}",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return canceled, func() {}
+			},
+			expected: context.Canceled,
+			err:      "Execution canceled: context canceled.\n[line 1]",
+		}, {
+			name: "canceled before loop body",
+			code: "print 0;\nwhile (true) { print 1; }",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return canceled, func() {}
+			},
+			output:   "0\n",
+			expected: context.Canceled,
+			err:      "Execution canceled: context canceled.\n[line 2]",
+		},
+	} {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			tokens, err := token.NewScanner([]byte(tc.code)).Scan()
+			require.NoError(t, err)
+			statements, err := ast.NewParser(tokens).Parse()
+			require.NoError(t, err)
+			var out bytes.Buffer
+			interpreter := New(&out)
+			require.NoError(t, interpreter.Resolve(statements...))
+
+			ctx, cancel := tc.ctx()
+			defer cancel()
+			err = interpreter.InterpretContext(ctx, statements...)
+			assert.Equal(t, tc.output, out.String())
+
+			assert.ErrorIs(t, err, tc.expected)
+			var runtimeErr *RuntimeError
+			require.ErrorAs(t, err, &runtimeErr)
+			assert.Equal(t, Canceled, runtimeErr.Kind)
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+}
+
+func TestEvalContextInNative(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	interpreter := New(io.Discard)
+	interpreter.DefineNative("stop", 0, func([]Value) (Value, error) {
+		cancel()
+		return Value{}, nil
+	})
+	// natives that evaluate code run in the context of the calling script
+	interpreter.DefineNative("run", 1, func(arguments []Value) (Value, error) {
+		src, _ := arguments[0].AsString()
+		return interpreter.Eval(src)
+	})
+
+	_, err := interpreter.EvalContext(ctx, "run(\"stop(); while (true) {}\");")
+	assert.ErrorIs(t, err, context.Canceled)
+
+	// the context no longer applies once EvalContext returns
+	value, err := interpreter.Eval("run(\"1 + 1\")")
+	require.NoError(t, err)
+	assert.Equal(t, MakeNumber(2), value)
+}
+
+func TestCallContext(t *testing.T) {
+	t.Parallel()
+
+	var calls int
+	interpreter := New(io.Discard)
+	interpreter.DefineNative("count", 0, func([]Value) (Value, error) {
+		calls++
+		return Value{}, nil
+	})
+	_, err := interpreter.Eval("fun spin() {\n  while (true) {}\n}")
+	require.NoError(t, err)
+	spin, _ := interpreter.GetGlobal("spin")
+	count, _ := interpreter.GetGlobal("count")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = interpreter.CallContext(ctx, spin)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.EqualError(t, err, "Execution canceled: context deadline exceeded.\n[line 2]")
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = interpreter.CallContext(canceled, count)
+	var runtimeErr *RuntimeError
+	require.ErrorAs(t, err, &runtimeErr)
+	assert.Equal(t, Canceled, runtimeErr.Kind)
+	assert.Zero(t, calls, "a canceled call does not run the callee")
+
+	// the context no longer applies once CallContext returns
+	_, err = interpreter.Call(count)
+	require.NoError(t, err)
+	assert.Equal(t, 1, calls)
+}
+
+// TestInterpretUnresolved runs statements that were not resolved before, as
+// embedders calling Interpret directly do
+func TestInterpretUnresolved(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name     string
+		code     string
+		expected string
+		err      string
+	}{
+		{
+			name:     "block local",
+			code:     "{ var a = 1; print a; }",
+			expected: "1\n",
+		}, {
+			name: "super outside of a class",
+			code: "fun f() { super.x; } f();",
+			err:  "[line 1] Error at 'super': Can't use 'super' outside of a class.",
+		},
+	} {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			tokens, err := token.NewScanner([]byte(tc.code)).Scan()
+			require.NoError(t, err)
+			statements, err := ast.NewParser(tokens).Parse()
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+			err = New(&buf).Interpret(statements...)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, buf.String())
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package interpreter
+
+import (
+	"time"
+)
+
+// NativeFunc implements a native function in Go. A returned error is raised
+// as a runtime error at the call.
+type NativeFunc func(arguments []Value) (Value, error)
+
+// native is a function implemented in Go
+type native struct {
+	name  string
+	arity int
+	fn    NativeFunc
+}
+
+func (n *native) Arity() int {
+	return n.arity
+}
+
+func (n *native) Call(_ *Interpreter, arguments []Value) (Value, error) {
+	return n.fn(arguments)
+}
+
+func (n *native) String() string {
+	return "<native fn>"
+}
+
+// DefineNative defines a global function implemented in Go, which scripts
+// call with arity arguments. Natives must be defined before the scripts that
+// use them are interpreted. Defining a name again replaces the previous
+// value.
+func (i *Interpreter) DefineNative(name string, arity int, fn NativeFunc) {
+	i.globals.define(name, Value{&native{name: name, arity: arity, fn: fn}})
+}
+
+// defineNatives defines the natives every interpreter provides
+func (i *Interpreter) defineNatives() {
+	i.DefineNative("clock", 0, clock)
+}
+
+// clock returns the number of seconds since the Unix epoch
+func clock([]Value) (Value, error) {
+	return MakeNumber(float64(time.Now().UnixNano()) / float64(time.Second)), nil
+}
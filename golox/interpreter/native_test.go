@@ -0,0 +1,95 @@
+package interpreter
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/cornelmarck/crafting-interpreters/golox/ast"
+	"github.com/cornelmarck/crafting-interpreters/golox/token"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefineNative(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name       string
+		code       string
+		expected   string
+		err        string
+		stackTrace string
+	}{
+		{
+			name:     "call",
+			code:     "print add(1, 2);",
+			expected: "3\n",
+		}, {
+			name:     "callback",
+			code:     "fun twice(x) { return 2 * x; }\nprint apply(twice, 4);",
+			expected: "8\n",
+		}, {
+			name:     "print",
+			code:     "print add; print clock;",
+			expected: "<native fn>\n<native fn>\n",
+		}, {
+			name:     "clock",
+			code:     "var start = clock(); print clock() >= start;",
+			expected: "true\n",
+		}, {
+			name: "arity mismatch",
+			code: "add(1);",
+			err:  "Expected 2 arguments but got 1.\n[line 1]",
+		}, {
+			name: "error",
+			code: "fun f() {\n  add(1, \"a\");\n}\nf();",
+			err:  "add: arguments must be numbers\n[line 2]",
+			stackTrace: "" +
+				"[line 2] in add()\n" +
+				"[line 2] in f()\n" +
+				"[line 4] in script\n",
+		},
+	} {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var out bytes.Buffer
+			interpreter := New(&out)
+			interpreter.DefineNative("add", 2, func(arguments []Value) (Value, error) {
+				a, aok := arguments[0].AsNumber()
+				b, bok := arguments[1].AsNumber()
+				if !aok || !bok {
+					return Value{}, errors.New("add: arguments must be numbers")
+				}
+				return MakeNumber(a + b), nil
+			})
+			interpreter.DefineNative("apply", 2, func(arguments []Value) (Value, error) {
+				return interpreter.Call(arguments[0], arguments[1:]...)
+			})
+
+			tokens, err := token.NewScanner([]byte(tc.code)).Scan()
+			require.NoError(t, err)
+			statements, err := ast.NewParser(tokens).Parse()
+			require.NoError(t, err)
+			require.NoError(t, interpreter.Resolve(statements...))
+			err = interpreter.Interpret(statements...)
+
+			if tc.err == "" {
+				require.NoError(t, err)
+				assert.Equal(t, tc.expected, out.String())
+				return
+			}
+			var runtimeErr *RuntimeError
+			require.True(t, errors.As(err, &runtimeErr), "expected a runtime error, got %v", err)
+			assert.Equal(t, tc.err, runtimeErr.Error())
+			if tc.stackTrace != "" {
+				assert.Equal(t, NativeError, runtimeErr.Kind)
+				assert.Equal(t, tc.stackTrace, runtimeErr.StackTrace())
+			}
+		})
+	}
+}
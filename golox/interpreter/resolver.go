@@ -0,0 +1,259 @@
+package interpreter
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/cornelmarck/crafting-interpreters/golox/ast"
+	"github.com/cornelmarck/crafting-interpreters/golox/token"
+)
+
+type functionType int
+
+const (
+	noFunction functionType = iota
+	functionBody
+	initializerBody
+	methodBody
+)
+
+type classType int
+
+const (
+	noClass classType = iota
+	classBody
+	subclassBody
+)
+
+// resolver is a static pass over the syntax tree that runs before the
+// statements are interpreted. It binds every local variable reference to the
+// scope that declares it, and reports the errors that can be detected without
+// running the code.
+type resolver struct {
+	locals map[ast.Expression]int
+
+	// scopes is a stack of the local scopes around the current node. A
+	// variable maps to true once its initializer has been resolved.
+	// The global scope is not tracked.
+	scopes []map[string]bool
+
+	currentFunction functionType
+	currentClass    classType
+
+	errors token.ErrorList
+}
+
+// Resolve reports the static errors in the statements without running
+// anything. Interpret resolves the statements it runs itself.
+func (i *Interpreter) Resolve(statements ...ast.Statement) error {
+	_, err := resolve(statements)
+	return err
+}
+
+// resolve binds the variables in the statements to the scopes they are
+// declared in. The returned locals belong to these statements only, so they
+// are released with the functions declared in them.
+func resolve(statements []ast.Statement) (map[ast.Expression]int, error) {
+	r := &resolver{
+		locals: make(map[ast.Expression]int),
+	}
+	r.resolveStatements(statements)
+	r.errors.Sort()
+	return r.locals, r.errors.Err()
+}
+
+func (r *resolver) resolveStatements(statements []ast.Statement) {
+	for _, s := range statements {
+		r.resolveStatement(s)
+	}
+}
+
+func (r *resolver) resolveStatement(statement ast.Statement) {
+	switch node := statement.(type) {
+	case ast.BlockStatement:
+		r.beginScope()
+		r.resolveStatements(node.Statements)
+		r.endScope()
+	case ast.VariableDeclaration:
+		r.declare(node.Name, node.Pos)
+		if node.Initializer != nil {
+			r.resolveExpression(node.Initializer)
+		}
+		r.define(node.Name)
+	case ast.FunctionDeclaration:
+		// a function can refer to itself, so it is defined before its body
+		r.declare(node.Name, node.Pos)
+		r.define(node.Name)
+		r.resolveFunction(node, functionBody)
+	case ast.ClassDeclaration:
+		r.resolveClass(node)
+	case ast.ExpressionStatement:
+		r.resolveExpression(node.Expression)
+	case ast.PrintStatement:
+		r.resolveExpression(node.Expression)
+	case ast.IfStatement:
+		r.resolveExpression(node.Condition)
+		r.resolveStatement(node.Then)
+		if node.Else != nil {
+			r.resolveStatement(node.Else)
+		}
+	case ast.WhileStatement:
+		r.resolveExpression(node.Condition)
+		r.resolveStatement(node.Body)
+	case ast.ReturnStatement:
+		if r.currentFunction == noFunction {
+			r.errors.Add(node.Pos, " at 'return'", "Can't return from top-level code.")
+		}
+		if node.Value != nil {
+			if r.currentFunction == initializerBody {
+				r.errors.Add(node.Pos, " at 'return'", "Can't return a value from an initializer.")
+			}
+			r.resolveExpression(node.Value)
+		}
+	default:
+		r.errors.Add(token.Position{}, "", fmt.Sprintf("Unknown statement: %s.", reflect.TypeOf(statement).String()))
+	}
+}
+
+func (r *resolver) resolveFunction(node ast.FunctionDeclaration, kind functionType) {
+	enclosingFunction := r.currentFunction
+	r.currentFunction = kind
+	defer func() { r.currentFunction = enclosingFunction }()
+
+	r.beginScope()
+	for _, param := range node.Params {
+		r.declare(param.Name, param.Pos)
+		r.define(param.Name)
+	}
+	r.resolveStatements(node.Body)
+	r.endScope()
+}
+
+func (r *resolver) resolveClass(node ast.ClassDeclaration) {
+	enclosingClass := r.currentClass
+	r.currentClass = classBody
+	defer func() { r.currentClass = enclosingClass }()
+
+	r.declare(node.Name, node.Pos)
+	r.define(node.Name)
+
+	// mirrors the scopes the interpreter creates for 'super' and 'this'
+	if node.Superclass != nil {
+		r.currentClass = subclassBody
+		r.resolveExpression(node.Superclass)
+
+		r.beginScope()
+		r.scopes[len(r.scopes)-1]["super"] = true
+	}
+
+	r.beginScope()
+	r.scopes[len(r.scopes)-1]["this"] = true
+	for _, method := range node.Methods {
+		kind := methodBody
+		if method.Name == "init" {
+			kind = initializerBody
+		}
+		r.resolveFunction(method, kind)
+	}
+	r.endScope()
+
+	if node.Superclass != nil {
+		r.endScope()
+	}
+}
+
+func (r *resolver) resolveExpression(expr ast.Expression) {
+	switch node := expr.(type) {
+	case ast.BooleanExpression, ast.NilExpression, ast.NumberExpression, ast.StringExpression:
+	case *ast.VariableExpression:
+		if len(r.scopes) > 0 {
+			if defined, ok := r.scopes[len(r.scopes)-1][node.Name]; ok && !defined {
+				r.errors.Add(node.Pos, at(node.Name), "Can't read local variable in its own initializer.")
+			}
+		}
+		r.resolveLocal(node, node.Name)
+	case *ast.AssignExpression:
+		r.resolveExpression(node.Value)
+		r.resolveLocal(node, node.Name)
+	case *ast.ThisExpression:
+		if r.currentClass == noClass {
+			r.errors.Add(node.Pos, " at 'this'", "Can't use 'this' outside of a class.")
+			return
+		}
+		r.resolveLocal(node, "this")
+	case *ast.SuperExpression:
+		switch r.currentClass {
+		case noClass:
+			r.errors.Add(node.Pos, " at 'super'", "Can't use 'super' outside of a class.")
+		case classBody:
+			r.errors.Add(node.Pos, " at 'super'", "Can't use 'super' in a class with no superclass.")
+		}
+		r.resolveLocal(node, "super")
+	case *ast.GroupingExpression:
+		r.resolveExpression(node.Expression)
+	case *ast.UrnaryExpression:
+		r.resolveExpression(node.Right)
+	case *ast.BinaryExpression:
+		r.resolveExpression(node.Left)
+		r.resolveExpression(node.Right)
+	case *ast.LogicalExpression:
+		r.resolveExpression(node.Left)
+		r.resolveExpression(node.Right)
+	case *ast.CallExpression:
+		r.resolveExpression(node.Callee)
+		for _, argument := range node.Arguments {
+			r.resolveExpression(argument)
+		}
+	case *ast.GetExpression:
+		r.resolveExpression(node.Object)
+	case *ast.SetExpression:
+		r.resolveExpression(node.Value)
+		r.resolveExpression(node.Object)
+	default:
+		r.errors.Add(token.Position{}, "", fmt.Sprintf("Unknown expression: %s.", reflect.TypeOf(expr).String()))
+	}
+}
+
+// resolveLocal records the distance to the innermost scope that declares the
+// name. Names that are not found are assumed to be global.
+func (r *resolver) resolveLocal(node ast.Expression, name string) {
+	for idx := len(r.scopes) - 1; idx >= 0; idx-- {
+		if _, ok := r.scopes[idx][name]; ok {
+			r.locals[node] = len(r.scopes) - 1 - idx
+			return
+		}
+	}
+}
+
+func (r *resolver) beginScope() {
+	r.scopes = append(r.scopes, make(map[string]bool))
+}
+
+func (r *resolver) endScope() {
+	r.scopes = r.scopes[:len(r.scopes)-1]
+}
+
+// declare adds the name to the innermost scope, marking it as not ready for
+// use until define is called
+func (r *resolver) declare(name string, pos token.Position) {
+	if len(r.scopes) == 0 {
+		return
+	}
+	scope := r.scopes[len(r.scopes)-1]
+	if _, ok := scope[name]; ok {
+		r.errors.Add(pos, at(name), "Already a variable with this name in this scope.")
+	}
+	scope[name] = false
+}
+
+func (r *resolver) define(name string) {
+	if len(r.scopes) == 0 {
+		return
+	}
+	r.scopes[len(r.scopes)-1][name] = true
+}
+
+// at describes the location of an error reported at the identifier
+func at(name string) string {
+	return fmt.Sprintf(" at '%s'", name)
+}
@@ -0,0 +1,80 @@
+package interpreter
+
+import (
+	"io"
+	"testing"
+
+	"github.com/cornelmarck/crafting-interpreters/golox/ast"
+	"github.com/cornelmarck/crafting-interpreters/golox/token"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolverErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		Name  string
+		Code  string
+		Error string
+	}{
+		{
+			Name: "valid program",
+			Code: `
+				class A { method() { return this; } }
+				class B < A { method() { return super.method(); } }
+				fun f(a) { var b = a; { var a = b; } }
+			`,
+		}, {
+			Name:  "read local in its own initializer",
+			Code:  `{ var a = a; }`,
+			Error: "Can't read local variable in its own initializer.",
+		}, {
+			Name:  "duplicate local",
+			Code:  `{ var a = 1; var a = 2; }`,
+			Error: "Already a variable with this name in this scope.",
+		}, {
+			Name:  "duplicate parameter",
+			Code:  `fun f(a, a) {}`,
+			Error: "Already a variable with this name in this scope.",
+		}, {
+			Name:  "return at top level",
+			Code:  `return 1;`,
+			Error: "Can't return from top-level code.",
+		}, {
+			Name:  "return value from initializer",
+			Code:  `class A { init() { return 1; } }`,
+			Error: "Can't return a value from an initializer.",
+		}, {
+			Name:  "this outside of a class",
+			Code:  `fun f() { return this; }`,
+			Error: "Can't use 'this' outside of a class.",
+		}, {
+			Name:  "super outside of a class",
+			Code:  `super.method();`,
+			Error: "Can't use 'super' outside of a class.",
+		}, {
+			Name:  "super without superclass",
+			Code:  `class A { method() { super.method(); } }`,
+			Error: "Can't use 'super' in a class with no superclass.",
+		},
+	} {
+		tc := tc
+
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			tokens, err := token.NewScanner([]byte(tc.Code)).Scan()
+			assert.NoError(t, err)
+			statements, err := ast.NewParser(tokens).Parse()
+			assert.NoError(t, err)
+
+			err = New(io.Discard).Resolve(statements...)
+			if tc.Error == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tc.Error)
+			}
+		})
+	}
+}
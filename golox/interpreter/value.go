@@ -0,0 +1,228 @@
+package interpreter
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+
+	"github.com/cornelmarck/crafting-interpreters/golox/token"
+)
+
+// Kind is the type of a Lox value
+type Kind int
+
+const (
+	NilKind      Kind = iota // nil
+	BoolKind                 // true or false
+	NumberKind               // a double precision number
+	StringKind               // an immutable string
+	CallableKind             // a function, class or native function
+	InstanceKind             // an instance of a class
+)
+
+func (k Kind) String() string {
+	switch k {
+	case NilKind:
+		return "nil"
+	case BoolKind:
+		return "boolean"
+	case NumberKind:
+		return "number"
+	case StringKind:
+		return "string"
+	case CallableKind:
+		return "callable"
+	case InstanceKind:
+		return "instance"
+	default:
+		return fmt.Sprintf("Kind(%d)", int(k))
+	}
+}
+
+// Value is a Lox value. The zero Value is nil.
+type Value struct {
+	// v is nil, a bool, a float64, a string, a Callable or an *instance
+	v any
+}
+
+// MakeBool returns the Lox boolean b
+func MakeBool(b bool) Value {
+	return Value{b}
+}
+
+// MakeNumber returns the Lox number f
+func MakeNumber(f float64) Value {
+	return Value{f}
+}
+
+// MakeString returns the Lox string s
+func MakeString(s string) Value {
+	return Value{s}
+}
+
+// MakeCallable returns a value that Lox code can call. A nil Callable is
+// the nil value. Lox compares callables with ==, so MakeCallable panics if
+// the type of c is not comparable. Use a pointer type to make every callable
+// only equal to itself.
+func MakeCallable(c Callable) Value {
+	if c == nil {
+		return Value{}
+	}
+	if !reflect.TypeOf(c).Comparable() {
+		panic(fmt.Sprintf("interpreter: MakeCallable with uncomparable type %T", c))
+	}
+	return Value{c}
+}
+
+func (v Value) Kind() Kind {
+	switch v.v.(type) {
+	case nil:
+		return NilKind
+	case bool:
+		return BoolKind
+	case float64:
+		return NumberKind
+	case string:
+		return StringKind
+	case *instance:
+		return InstanceKind
+	default:
+		return CallableKind
+	}
+}
+
+func (v Value) IsNil() bool {
+	return v.v == nil
+}
+
+// AsBool returns the boolean, or false if the value is not a boolean
+func (v Value) AsBool() (bool, bool) {
+	b, ok := v.v.(bool)
+	return b, ok
+}
+
+// AsNumber returns the number, or false if the value is not a number
+func (v Value) AsNumber() (float64, bool) {
+	f, ok := v.v.(float64)
+	return f, ok
+}
+
+// AsString returns the string, or false if the value is not a string. Use
+// String to format any value.
+func (v Value) AsString() (string, bool) {
+	s, ok := v.v.(string)
+	return s, ok
+}
+
+// AsCallable returns the callable, or false if the value can not be called
+func (v Value) AsCallable() (Callable, bool) {
+	c, ok := v.v.(Callable)
+	return c, ok
+}
+
+// ClassName returns the name of the class of an instance, or false if the
+// value is not an instance
+func (v Value) ClassName() (string, bool) {
+	in, ok := v.v.(*instance)
+	if !ok {
+		return "", false
+	}
+	return in.class.name, true
+}
+
+// Field returns the named field of an instance, or false if the value is not
+// an instance or has no such field. Methods are not fields.
+func (v Value) Field(name string) (Value, bool) {
+	in, ok := v.v.(*instance)
+	if !ok {
+		return Value{}, false
+	}
+	value, ok := in.fields[name]
+	return value, ok
+}
+
+// SetField sets the named field of an instance like an assignment to the
+// property in Lox. It reports false if the value is not an instance.
+func (v Value) SetField(name string, value Value) bool {
+	in, ok := v.v.(*instance)
+	if ok {
+		in.set(name, value)
+	}
+	return ok
+}
+
+// Truthy reports whether the value counts as true in a condition. Only nil
+// and false are falsey.
+func (v Value) Truthy() bool {
+	switch v := v.v.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	default:
+		return true
+	}
+}
+
+// Equal reports whether two Lox values are equal. Values of different kinds
+// are never equal and numbers compare by IEEE 754, so NaN is not equal to
+// itself. Functions, classes and instances are only equal to themselves.
+func (v Value) Equal(w Value) bool {
+	// MakeCallable only accepts comparable callables, so == does not panic
+	return v.v == w.v
+}
+
+// String returns the text print shows for the value. Numbers are written in
+// decimal notation without a fractional part if they are integers, and nil
+// is written as "nil". Callables that are not fmt.Stringers are written as
+// "<native fn>".
+func (v Value) String() string {
+	switch v := v.v.(type) {
+	case nil:
+		return "nil"
+	case float64:
+		return formatNumber(v)
+	case fmt.Stringer:
+		// functions, classes, natives and instances
+		return v.String()
+	case Callable:
+		return "<native fn>"
+	default:
+		// booleans and strings
+		return fmt.Sprint(v)
+	}
+}
+
+func formatNumber(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "Infinity"
+	case math.IsInf(v, -1):
+		return "-Infinity"
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// checkNumber returns the number an operand holds, or a type error at the
+// operator
+func (i *Interpreter) checkNumber(pos token.Position, operand Value) (float64, error) {
+	f, ok := operand.AsNumber()
+	if !ok {
+		return 0, i.runtimeError(TypeError, pos, "Operand must be a number.")
+	}
+	return f, nil
+}
+
+// checkNumbers returns the numbers both operands hold, or a type error at
+// the operator
+func (i *Interpreter) checkNumbers(pos token.Position, left, right Value) (float64, float64, error) {
+	l, lok := left.AsNumber()
+	r, rok := right.AsNumber()
+	if !lok || !rok {
+		return 0, 0, i.runtimeError(TypeError, pos, "Operands must be numbers.")
+	}
+	return l, r, nil
+}
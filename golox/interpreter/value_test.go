@@ -0,0 +1,157 @@
+package interpreter
+
+import (
+	"math"
+	"testing"
+
+	"github.com/cornelmarck/crafting-interpreters/golox/ast"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValueString(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		Name     string
+		Value    Value
+		Expected string
+	}{
+		{Name: "nil", Value: Value{}, Expected: "nil"},
+		{Name: "bool", Value: MakeBool(true), Expected: "true"},
+		{Name: "string", Value: MakeString("hello"), Expected: "hello"},
+		{Name: "integer", Value: MakeNumber(3), Expected: "3"},
+		{Name: "fraction", Value: MakeNumber(-0.001), Expected: "-0.001"},
+		{Name: "negative zero", Value: MakeNumber(math.Copysign(0, -1)), Expected: "-0"},
+		{Name: "large integer", Value: MakeNumber(12345678901234567890), Expected: "12345678901234567000"},
+		{Name: "NaN", Value: MakeNumber(math.NaN()), Expected: "NaN"},
+		{Name: "infinity", Value: MakeNumber(math.Inf(-1)), Expected: "-Infinity"},
+		{Name: "class", Value: MakeCallable(&class{name: "Foo"}), Expected: "Foo"},
+		{Name: "instance", Value: Value{&instance{class: &class{name: "Foo"}}}, Expected: "Foo instance"},
+		{Name: "embedder callable", Value: MakeCallable(&bare{}), Expected: "<native fn>"},
+	} {
+		tc := tc
+
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.Expected, tc.Value.String())
+		})
+	}
+}
+
+func TestValueKind(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, NilKind, Value{}.Kind())
+	assert.Equal(t, NilKind, MakeCallable(nil).Kind())
+	assert.Equal(t, BoolKind, MakeBool(false).Kind())
+	assert.Equal(t, NumberKind, MakeNumber(1).Kind())
+	assert.Equal(t, StringKind, MakeString("").Kind())
+	assert.Equal(t, CallableKind, MakeCallable(&native{}).Kind())
+	assert.Equal(t, InstanceKind, Value{&instance{}}.Kind())
+
+	n, ok := MakeNumber(1.5).AsNumber()
+	assert.True(t, ok)
+	assert.Equal(t, 1.5, n)
+	_, ok = MakeString("1.5").AsNumber()
+	assert.False(t, ok)
+	_, ok = Value{&instance{}}.AsCallable()
+	assert.False(t, ok)
+
+	_, ok = MakeString("Foo").ClassName()
+	assert.False(t, ok)
+	_, ok = MakeNumber(1).Field("x")
+	assert.False(t, ok)
+	assert.False(t, MakeNumber(1).SetField("x", Value{}))
+
+	assert.False(t, Value{}.Truthy())
+	assert.False(t, MakeBool(false).Truthy())
+	assert.True(t, MakeNumber(0).Truthy())
+	assert.True(t, MakeString("").Truthy())
+}
+
+// bare is a Callable that is not a fmt.Stringer
+type bare struct {
+	calls []int
+}
+
+func (*bare) Arity() int { return 0 }
+
+func (*bare) Call(*Interpreter, []Value) (Value, error) { return Value{}, nil }
+
+// uncomparable is a Callable that can not be compared with ==
+type uncomparable func()
+
+func (uncomparable) Arity() int { return 0 }
+
+func (uncomparable) Call(*Interpreter, []Value) (Value, error) { return Value{}, nil }
+
+func TestValueInstance(t *testing.T) {
+	t.Parallel()
+
+	greet := &function{declaration: ast.FunctionDeclaration{Name: "greet"}}
+	foo := Value{&instance{
+		class:  &class{name: "Foo", methods: map[string]*function{"greet": greet}},
+		fields: map[string]Value{"x": MakeNumber(1)},
+	}}
+
+	name, ok := foo.ClassName()
+	assert.True(t, ok)
+	assert.Equal(t, "Foo", name)
+
+	x, ok := foo.Field("x")
+	assert.True(t, ok)
+	assert.Equal(t, MakeNumber(1), x)
+	_, ok = foo.Field("y")
+	assert.False(t, ok)
+	_, ok = foo.Field("greet")
+	assert.False(t, ok, "methods are not fields")
+
+	assert.True(t, foo.SetField("y", MakeString("y")))
+	y, ok := foo.Field("y")
+	assert.True(t, ok)
+	assert.Equal(t, MakeString("y"), y)
+}
+
+func TestValueEqual(t *testing.T) {
+	t.Parallel()
+
+	foo := MakeCallable(&class{name: "Foo"})
+	u := MakeCallable(&bare{})
+
+	for _, tc := range []struct {
+		Name     string
+		A, B     Value
+		Expected bool
+	}{
+		{Name: "nil", A: Value{}, B: Value{}, Expected: true},
+		{Name: "nil and false", A: Value{}, B: MakeBool(false), Expected: false},
+		{Name: "false and zero", A: MakeBool(false), B: MakeNumber(0), Expected: false},
+		{Name: "number and string", A: MakeNumber(0), B: MakeString("0"), Expected: false},
+		{Name: "numbers", A: MakeNumber(1), B: MakeNumber(1), Expected: true},
+		{Name: "zero and negative zero", A: MakeNumber(0), B: MakeNumber(math.Copysign(0, -1)), Expected: true},
+		{Name: "NaN", A: MakeNumber(math.NaN()), B: MakeNumber(math.NaN()), Expected: false},
+		{Name: "strings", A: MakeString("str"), B: MakeString("str"), Expected: true},
+		{Name: "same class", A: foo, B: foo, Expected: true},
+		{Name: "classes with the same name", A: foo, B: MakeCallable(&class{name: "Foo"}), Expected: false},
+		{Name: "same embedder callable", A: u, B: u, Expected: true},
+		{Name: "embedder callables", A: u, B: MakeCallable(&bare{}), Expected: false},
+	} {
+		tc := tc
+
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.Expected, tc.A.Equal(tc.B))
+		})
+	}
+}
+
+func TestMakeCallableUncomparable(t *testing.T) {
+	t.Parallel()
+
+	assert.PanicsWithValue(t, "interpreter: MakeCallable with uncomparable type interpreter.uncomparable", func() {
+		MakeCallable(uncomparable(nil))
+	})
+}
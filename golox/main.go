@@ -1,7 +1,7 @@
 package main
 
 import (
-	"bufio"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -11,65 +11,57 @@ import (
 	"github.com/cornelmarck/crafting-interpreters/golox/token"
 )
 
+// exit codes, as defined by sysexits.h
+const (
+	exitUsage    = 64 // the command was used incorrectly
+	exitDataErr  = 65 // the script has static errors
+	exitSoftware = 70 // the script failed at runtime
+	exitIOErr    = 74 // the script could not be read
+)
+
 func main() {
-	if len(os.Args) == 1 {
-		runPrompt()
-	} else if len(os.Args) == 2 {
-		runFile(os.Args[1])
-	} else {
-		fmt.Println("usage: jlox [script]")
-		os.Exit(64)
-	}
+	c := &cli{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}
+	os.Exit(c.main(os.Args[1:]))
 }
 
-func runPrompt() {
-	reader := bufio.NewScanner(os.Stdin)
-	fmt.Println("Enter text (Ctrl+D to stop)")
+// compile scans, parses and resolves the source. The parser skips illegal
+// tokens, so syntax errors after a lexical error are still reported.
+func compile(interpreter *interpreter.Interpreter, src []byte) ([]ast.Statement, error) {
+	tokens, scanErr := token.NewScanner(src).Scan()
+	statements, parseErr := ast.NewParser(tokens).Parse()
+	if err := errors.Join(scanErr, parseErr); err != nil {
+		return nil, err
+	}
 
-	for {
-		fmt.Print("> ")
-		if ok := reader.Scan(); !ok {
-			return
-		}
-		line := reader.Bytes()
-		run(line)
+	if err := interpreter.Resolve(statements...); err != nil {
+		return nil, err
 	}
+	return statements, nil
 }
 
-func runFile(name string) {
-	fmt.Println("running file")
-	file, err := os.Open(name)
-	if err != nil {
-		fmt.Printf("could not open '%s': %v", name, err)
-		os.Exit(2)
+// reportError prints every error in the format of the reference
+// implementation. Syntax errors are printed one per line. A runtime error
+// raised inside a function is followed by its stack trace, the trace of an
+// error at the top level would only repeat the line.
+func reportError(w io.Writer, err error) {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, err := range joined.Unwrap() {
+			reportError(w, err)
+		}
+		return
 	}
 
-	src, err := io.ReadAll(file)
-	file.Close()
-	if err != nil {
-		fmt.Printf("could not copy src: %v", err)
-		os.Exit(1)
+	var list token.ErrorList
+	if errors.As(err, &list) {
+		for _, e := range list {
+			fmt.Fprintln(w, e)
+		}
+		return
 	}
+	fmt.Fprintln(w, err)
 
-	run(src)
-}
-
-func run(src []byte) {
-	scanner := token.NewScanner(src)
-	tokens := scanner.Scan()
-
-	parser := ast.NewParser(tokens)
-	statements, err := parser.Parse()
-	handleError(err)
-
-	interpreter := interpreter.New(os.Stdout)
-	err = interpreter.Interpret(statements...)
-	handleError(err)
-}
-
-func handleError(err error) {
-	if err != nil {
-		fmt.Printf("runtime error: %v\n", err)
-		os.Exit(0)
+	var runtimeErr *interpreter.RuntimeError
+	if errors.As(err, &runtimeErr) && len(runtimeErr.Trace) > 0 {
+		fmt.Fprint(w, runtimeErr.StackTrace())
 	}
 }
@@ -0,0 +1,346 @@
+// Package readline is a minimal line editor for interactive terminals. It
+// supports the common readline key bindings, a history of previous lines and
+// tab completion. If the input is not a terminal, lines are read as is.
+package readline
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// ErrInterrupt is returned by ReadLine when the user presses Ctrl-C
+var ErrInterrupt = errors.New("interrupt")
+
+// maxHistory is the number of lines the history keeps
+const maxHistory = 1000
+
+// control characters
+const (
+	ctrlA     = 1
+	ctrlB     = 2
+	ctrlC     = 3
+	ctrlD     = 4
+	ctrlE     = 5
+	ctrlF     = 6
+	ctrlH     = 8
+	tab       = 9
+	ctrlK     = 11
+	ctrlL     = 12
+	enter     = 13
+	ctrlN     = 14
+	ctrlP     = 16
+	ctrlU     = 21
+	ctrlW     = 23
+	esc       = 27
+	backspace = 127
+)
+
+type Editor struct {
+	// Complete returns the words the word before the cursor may be completed
+	// to. Words that do not start with the word are ignored, so it may return
+	// all known words. Completion is disabled if Complete is nil.
+	Complete func(word string) []string
+
+	in      *bufio.Reader
+	out     io.Writer
+	fd      int // file descriptor of the input terminal, -1 if not a terminal
+	history []string
+}
+
+func New(in io.Reader, out io.Writer) *Editor {
+	e := &Editor{
+		in:  bufio.NewReader(in),
+		out: out,
+		fd:  -1,
+	}
+	if IsTerminal(in) {
+		e.fd = int(in.(*os.File).Fd())
+	}
+	return e
+}
+
+// IsTerminal reports whether the reader is an interactive terminal
+func IsTerminal(r io.Reader) bool {
+	f, ok := r.(*os.File)
+	return ok && isTerminal(int(f.Fd()))
+}
+
+// ReadLine shows the prompt and returns the line the user entered, without
+// the line ending. It returns io.EOF at the end of the input, or if the user
+// presses Ctrl-D on an empty line, and ErrInterrupt if the user presses
+// Ctrl-C.
+func (e *Editor) ReadLine(prompt string) (string, error) {
+	if e.fd < 0 {
+		return e.readPlain(prompt)
+	}
+	restore, err := makeRaw(e.fd)
+	if err != nil {
+		return e.readPlain(prompt)
+	}
+	defer restore()
+
+	line, err := e.edit(prompt)
+	fmt.Fprint(e.out, "\r\n")
+	return line, err
+}
+
+func (e *Editor) readPlain(prompt string) (string, error) {
+	fmt.Fprint(e.out, prompt)
+	line, err := e.in.ReadString('\n')
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+	return strings.TrimRight(line, "\r\n"), err
+}
+
+// line is the state of the line being edited
+type line struct {
+	prompt string
+	buf    []rune
+	pos    int // cursor position in buf
+
+	history int    // index of the history entry shown, len(history) for the new line
+	saved   []rune // the new line, while browsing the history
+}
+
+func (e *Editor) edit(prompt string) (string, error) {
+	l := &line{prompt: prompt, history: len(e.history)}
+	e.refresh(l)
+
+	tabbed := false // the previous key was tab
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return "", err
+		}
+
+		switch r {
+		case enter, '\n':
+			return string(l.buf), nil
+		case ctrlC:
+			fmt.Fprint(e.out, "^C")
+			return "", ErrInterrupt
+		case ctrlD:
+			if len(l.buf) == 0 {
+				return "", io.EOF
+			}
+			l.delete()
+		case backspace, ctrlH:
+			if l.pos > 0 {
+				l.pos--
+				l.delete()
+			}
+		case ctrlA:
+			l.pos = 0
+		case ctrlE:
+			l.pos = len(l.buf)
+		case ctrlB:
+			l.pos = max(l.pos-1, 0)
+		case ctrlF:
+			l.pos = min(l.pos+1, len(l.buf))
+		case ctrlK:
+			l.buf = l.buf[:l.pos]
+		case ctrlU:
+			l.buf = slices.Delete(l.buf, 0, l.pos)
+			l.pos = 0
+		case ctrlW:
+			// the spaces before the cursor, then the word before them
+			start := l.pos
+			for start > 0 && unicode.IsSpace(l.buf[start-1]) {
+				start--
+			}
+			for start > 0 && !unicode.IsSpace(l.buf[start-1]) {
+				start--
+			}
+			l.buf = slices.Delete(l.buf, start, l.pos)
+			l.pos = start
+		case ctrlP:
+			e.browse(l, -1)
+		case ctrlN:
+			e.browse(l, 1)
+		case ctrlL:
+			fmt.Fprint(e.out, "\x1b[H\x1b[2J")
+		case tab:
+			e.complete(l, tabbed)
+		case esc:
+			e.escape(l)
+		default:
+			if unicode.IsPrint(r) {
+				l.buf = slices.Insert(l.buf, l.pos, r)
+				l.pos++
+			}
+		}
+		tabbed = r == tab
+		e.refresh(l)
+	}
+}
+
+// escape handles the escape sequences sent by the arrow, home, end and delete
+// keys
+func (e *Editor) escape(l *line) {
+	r, _, err := e.in.ReadRune()
+	if err != nil || (r != '[' && r != 'O') {
+		return
+	}
+
+	// parameters are digits, the sequence ends with the first other byte
+	var param strings.Builder
+	for {
+		r, _, err = e.in.ReadRune()
+		if err != nil {
+			return
+		}
+		if r < '0' || r > '9' {
+			break
+		}
+		param.WriteRune(r)
+	}
+
+	switch {
+	case r == 'A':
+		e.browse(l, -1)
+	case r == 'B':
+		e.browse(l, 1)
+	case r == 'C':
+		l.pos = min(l.pos+1, len(l.buf))
+	case r == 'D':
+		l.pos = max(l.pos-1, 0)
+	case r == 'H', r == '~' && (param.String() == "1" || param.String() == "7"):
+		l.pos = 0
+	case r == 'F', r == '~' && (param.String() == "4" || param.String() == "8"):
+		l.pos = len(l.buf)
+	case r == '~' && param.String() == "3":
+		l.delete()
+	}
+}
+
+// refresh redraws the line and moves the cursor to its position
+func (e *Editor) refresh(l *line) {
+	fmt.Fprintf(e.out, "\r%s%s\x1b[K", l.prompt, string(l.buf))
+	if n := len(l.buf) - l.pos; n > 0 {
+		fmt.Fprintf(e.out, "\x1b[%dD", n)
+	}
+}
+
+// browse replaces the line with the previous (-1) or next (1) history entry
+func (e *Editor) browse(l *line, step int) {
+	idx := l.history + step
+	if idx < 0 || idx > len(e.history) {
+		return
+	}
+	if l.history == len(e.history) {
+		l.saved = l.buf
+	}
+
+	l.history = idx
+	if idx == len(e.history) {
+		l.buf = l.saved
+	} else {
+		l.buf = []rune(e.history[idx])
+	}
+	l.pos = len(l.buf)
+}
+
+// complete extends the word before the cursor by the prefix all candidates
+// share. If that does not extend the word, a second tab lists the candidates.
+func (e *Editor) complete(l *line, tabbed bool) {
+	if e.Complete == nil {
+		return
+	}
+	start := l.wordStart()
+	word := string(l.buf[start:l.pos])
+	if word == "" {
+		return
+	}
+
+	var candidates []string
+	for _, candidate := range e.Complete(word) {
+		if strings.HasPrefix(candidate, word) {
+			candidates = append(candidates, candidate)
+		}
+	}
+	slices.Sort(candidates)
+	candidates = slices.Compact(candidates)
+	if len(candidates) == 0 {
+		fmt.Fprint(e.out, "\a")
+		return
+	}
+
+	prefix := candidates[0]
+	for _, candidate := range candidates[1:] {
+		for !strings.HasPrefix(candidate, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	if len(prefix) > len(word) {
+		l.buf = slices.Insert(l.buf, l.pos, []rune(prefix[len(word):])...)
+		l.pos += len([]rune(prefix)) - len([]rune(word))
+		return
+	}
+
+	if tabbed {
+		fmt.Fprintf(e.out, "\r\n%s\r\n", strings.Join(candidates, "  "))
+	} else {
+		fmt.Fprint(e.out, "\a")
+	}
+}
+
+// delete removes the character under the cursor
+func (l *line) delete() {
+	if l.pos < len(l.buf) {
+		l.buf = slices.Delete(l.buf, l.pos, l.pos+1)
+	}
+}
+
+// wordStart returns the start of the identifier before the cursor
+func (l *line) wordStart() int {
+	start := l.pos
+	for start > 0 && isWordChar(l.buf[start-1]) {
+		start--
+	}
+	return start
+}
+
+func isWordChar(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// AddHistory appends the line to the history, unless it is empty or repeats
+// the most recent entry
+func (e *Editor) AddHistory(entry string) {
+	if strings.TrimSpace(entry) == "" {
+		return
+	}
+	if len(e.history) > 0 && e.history[len(e.history)-1] == entry {
+		return
+	}
+	e.history = append(e.history, entry)
+	if len(e.history) > maxHistory {
+		e.history = e.history[len(e.history)-maxHistory:]
+	}
+}
+
+// LoadHistory adds the entries read from r, one entry per line
+func (e *Editor) LoadHistory(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		e.AddHistory(scanner.Text())
+	}
+	return scanner.Err()
+}
+
+// SaveHistory writes the history to w, one entry per line
+func (e *Editor) SaveHistory(w io.Writer) error {
+	for _, entry := range e.history {
+		if _, err := fmt.Fprintln(w, entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package readline
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEdit(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		Name     string
+		History  []string
+		Keys     string
+		Expected string
+		Err      error
+	}{
+		{
+			Name:     "insert",
+			Keys:     "print 1;\r",
+			Expected: "print 1;",
+		}, {
+			Name:     "backspace",
+			Keys:     "prinx\x7ft\r",
+			Expected: "print",
+		}, {
+			Name:     "move and insert",
+			Keys:     "rint\x01p\x05;\r",
+			Expected: "print;",
+		}, {
+			Name:     "arrow keys and delete",
+			Keys:     "abd\x1b[D\x1b[Dx\x1b[3~\x1b[C\x1b[Hy\x1b[Fz\r",
+			Expected: "yaxdz",
+		}, {
+			Name:     "kill to end and start",
+			Keys:     "one two three\x02\x02\x02\x02\x02\x02\x0b\x01\x06\x06\x06\x06\x15\r",
+			Expected: "two",
+		}, {
+			Name:     "delete word",
+			Keys:     "var answer = 42  \x17\x17\r",
+			Expected: "var answer ",
+		}, {
+			Name:     "history",
+			History:  []string{"first", "second"},
+			Keys:     "new\x1b[A\x1b[A\x1b[A\x1b[B\r",
+			Expected: "second",
+		}, {
+			Name:     "history back to the new line",
+			History:  []string{"first"},
+			Keys:     "new\x10\x0e!\r",
+			Expected: "new!",
+		}, {
+			Name:     "complete unique word",
+			Keys:     "pr\t 1;\r",
+			Expected: "print 1;",
+		}, {
+			Name:     "complete common prefix",
+			Keys:     "var c = cou\t\r",
+			Expected: "var c = counte",
+		}, {
+			Name: "interrupt",
+			Keys: "abc\x03",
+			Err:  ErrInterrupt,
+		}, {
+			Name: "end of input on empty line",
+			Keys: "\x04",
+			Err:  io.EOF,
+		}, {
+			Name:     "ctrl-d deletes under cursor",
+			Keys:     "ab\x02\x04\r",
+			Expected: "a",
+		},
+	} {
+		tc := tc
+
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			e := New(strings.NewReader(tc.Keys), io.Discard)
+			e.Complete = func(string) []string {
+				return []string{"print", "counter", "counted", "var"}
+			}
+			for _, entry := range tc.History {
+				e.AddHistory(entry)
+			}
+
+			line, err := e.edit("> ")
+			assert.Equal(t, tc.Err, err)
+			assert.Equal(t, tc.Expected, line)
+		})
+	}
+}
+
+func TestReadLineNotATerminal(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	e := New(strings.NewReader("print 1;\r\nlast"), &out)
+
+	line, err := e.ReadLine("> ")
+	assert.NoError(t, err)
+	assert.Equal(t, "print 1;", line)
+
+	line, err = e.ReadLine("> ")
+	assert.NoError(t, err)
+	assert.Equal(t, "last", line)
+
+	_, err = e.ReadLine("> ")
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, "> > > ", out.String())
+}
+
+func TestHistory(t *testing.T) {
+	t.Parallel()
+
+	e := New(strings.NewReader(""), io.Discard)
+	assert.NoError(t, e.LoadHistory(strings.NewReader("a\nb\nb\n\nc\n")))
+	e.AddHistory("c")
+	e.AddHistory("  ")
+	e.AddHistory("d")
+
+	var buf bytes.Buffer
+	assert.NoError(t, e.SaveHistory(&buf))
+	assert.Equal(t, "a\nb\nc\nd\n", buf.String())
+
+	for range maxHistory {
+		e.AddHistory(buf.String())
+		buf.WriteByte('x')
+	}
+	assert.Len(t, e.history, maxHistory)
+}
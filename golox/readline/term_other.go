@@ -0,0 +1,16 @@
+//go:build !linux && !darwin
+
+package readline
+
+import "errors"
+
+// line editing is not supported on this platform, the editor falls back to
+// reading plain lines
+
+func isTerminal(fd int) bool {
+	return false
+}
+
+func makeRaw(fd int) (restore func() error, err error) {
+	return nil, errors.New("raw mode is not supported on this platform")
+}
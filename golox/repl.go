@@ -0,0 +1,268 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cornelmarck/crafting-interpreters/golox/ast"
+	"github.com/cornelmarck/crafting-interpreters/golox/interpreter"
+	"github.com/cornelmarck/crafting-interpreters/golox/readline"
+	"github.com/cornelmarck/crafting-interpreters/golox/token"
+)
+
+// repl is an interactive session. The session shares a single interpreter, so
+// declarations persist between inputs, and errors are reported without
+// ending the session.
+type repl struct {
+	out         io.Writer // program output
+	err         io.Writer // diagnostics
+	interpreter *interpreter.Interpreter
+	timing      bool   // report how long each statement takes to run
+	historyFile string // file the input history persists in, empty to not persist it
+}
+
+func newREPL(out io.Writer, err io.Writer) *repl {
+	return &repl{
+		out:         out,
+		err:         err,
+		interpreter: interpreter.New(out, interpreter.WithStderr(err)),
+	}
+}
+
+// run reads and runs the input one statement at a time until the reader is
+// exhausted.
+//
+// Input that ends in the middle of a statement, like an open brace, continues
+// on the next line. An empty line ends the input regardless. Lines starting
+// with a colon are commands to the session itself, see command.
+//
+// The history only persists for input from a terminal, so piping a script
+// in does not replace it.
+func (r *repl) run(in io.Reader) {
+	editor := readline.New(in, r.out)
+	editor.Complete = r.completions
+	if readline.IsTerminal(in) {
+		r.loadHistory(editor)
+		defer r.saveHistory(editor)
+	}
+
+	var input []byte
+	for {
+		prompt := "> "
+		if len(input) > 0 {
+			prompt = "... "
+		}
+		line, err := editor.ReadLine(prompt)
+		if errors.Is(err, readline.ErrInterrupt) {
+			// discard the statement entered so far
+			input = input[:0]
+			continue
+		}
+		if err != nil {
+			return
+		}
+		editor.AddHistory(line)
+
+		if len(input) == 0 && strings.HasPrefix(line, ":") {
+			if err := r.command(line); err != nil {
+				reportError(r.err, err)
+			}
+			continue
+		}
+
+		input = append(input, line...)
+		input = append(input, '\n')
+
+		err = r.eval(input)
+		if len(line) > 0 && incomplete(err) {
+			continue
+		}
+		if err != nil {
+			reportError(r.err, err)
+		}
+		input = input[:0]
+	}
+}
+
+// eval runs the input entered at the prompt. Input that consists of a single
+// expression prints its value, the trailing semicolon is optional.
+func (r *repl) eval(input []byte) error {
+	statements, err := parseInput(input)
+	if err != nil {
+		return err
+	}
+
+	if len(statements) == 1 {
+		if statement, ok := statements[0].(ast.ExpressionStatement); ok {
+			statements[0] = ast.PrintStatement{Expression: statement.Expression}
+		}
+	}
+
+	if err := r.interpreter.Resolve(statements...); err != nil {
+		return err
+	}
+	return r.interpret(statements)
+}
+
+// parseInput parses the input as statements, or as a single expression if
+// the input is not a valid list of statements
+func parseInput(input []byte) ([]ast.Statement, error) {
+	tokens, scanErr := token.NewScanner(input).Scan()
+	statements, parseErr := ast.NewParser(tokens).Parse()
+	if scanErr == nil && parseErr != nil {
+		if expression, err := ast.NewParser(tokens).ParseExpression(); err == nil {
+			return []ast.Statement{ast.ExpressionStatement{Expression: expression}}, nil
+		}
+	}
+	if err := errors.Join(scanErr, parseErr); err != nil {
+		return nil, err
+	}
+	return statements, nil
+}
+
+// interpret runs the resolved statements one by one, so each can be timed
+func (r *repl) interpret(statements []ast.Statement) error {
+	for _, statement := range statements {
+		start := time.Now()
+		err := r.interpreter.Interpret(statement)
+		if r.timing {
+			fmt.Fprintf(r.out, "(%v)\n", time.Since(start))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// command runs a command to the session:
+//
+//	:env           list the global variables and their values
+//	:tokens <src>  print the tokens of the source
+//	:ast <src>     print the syntax tree of the source
+//	:load <file>   run a file in the session
+//	:reset         discard all declarations
+//	:time          toggle reporting how long each statement takes
+func (r *repl) command(line string) error {
+	cmd, arg, _ := strings.Cut(line, " ")
+	arg = strings.TrimSpace(arg)
+
+	switch cmd {
+	case ":env":
+		globals := r.interpreter.Globals()
+		for _, name := range slices.Sorted(maps.Keys(globals)) {
+			fmt.Fprintf(r.out, "%s = %s\n", name, globals[name])
+		}
+	case ":tokens":
+		tokens, err := token.NewScanner([]byte(arg)).Scan()
+		printTokens(r.out, tokens)
+		return err
+	case ":ast":
+		statements, err := parseInput([]byte(arg))
+		if err != nil {
+			return err
+		}
+		for _, statement := range statements {
+			if err := ast.Fprint(r.out, statement); err != nil {
+				return err
+			}
+		}
+	case ":load":
+		src, err := os.ReadFile(arg)
+		if err != nil {
+			return err
+		}
+		statements, err := compile(r.interpreter, src)
+		if err != nil {
+			return err
+		}
+		return r.interpret(statements)
+	case ":reset":
+		r.interpreter = interpreter.New(r.out, interpreter.WithStderr(r.err))
+	case ":time":
+		r.timing = !r.timing
+		if r.timing {
+			fmt.Fprintln(r.out, "timing on")
+		} else {
+			fmt.Fprintln(r.out, "timing off")
+		}
+	default:
+		return fmt.Errorf("unknown command %s, expected one of :env, :tokens, :ast, :load, :reset or :time", cmd)
+	}
+	return nil
+}
+
+// completions are the words the editor completes: the keywords and the
+// names of the global variables
+func (r *repl) completions(string) []string {
+	return append(token.Keywords(), slices.Collect(maps.Keys(r.interpreter.Globals()))...)
+}
+
+func (r *repl) loadHistory(editor *readline.Editor) {
+	if r.historyFile == "" {
+		return
+	}
+	f, err := os.Open(r.historyFile)
+	if err != nil {
+		// there is no history before the first session
+		return
+	}
+	defer f.Close()
+	if err := editor.LoadHistory(f); err != nil {
+		fmt.Fprintf(r.err, "could not load history: %v\n", err)
+	}
+}
+
+func (r *repl) saveHistory(editor *readline.Editor) {
+	if r.historyFile == "" {
+		return
+	}
+	f, err := os.Create(r.historyFile)
+	if err == nil {
+		err = editor.SaveHistory(f)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(r.err, "could not save history: %v\n", err)
+	}
+}
+
+// printTokens writes one token per line: its position, type and lexeme
+func printTokens(w io.Writer, tokens []token.Token) {
+	for _, tok := range tokens {
+		fmt.Fprintf(w, "%s\t%s\t%q\n", tok.Pos, tok.Type, tok.Lexeme)
+	}
+}
+
+// incomplete reports whether every error shows that the source ended before
+// a statement was complete. Syntax errors are reported before anything runs,
+// so the source can be run again once more input is added.
+func incomplete(err error) bool {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, err := range joined.Unwrap() {
+			if !incomplete(err) {
+				return false
+			}
+		}
+		return true
+	}
+
+	var list token.ErrorList
+	if !errors.As(err, &list) {
+		return false
+	}
+	for _, e := range list {
+		if !e.Incomplete {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestREPLEval(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	session := newREPL(&buf, &buf)
+
+	for _, tc := range []struct {
+		line     string
+		expected string
+		err      string
+	}{
+		{line: "var a = 1;"},
+		{line: "print a;", expected: "1\n"},
+		{line: "a + 2", expected: "3\n"},
+		{line: "a = 5;", expected: "5\n"},
+		{line: "undefined;", err: "Undefined variable 'undefined'.\n[line 1]"},
+		{line: "fun twice(x) { return x * 2; }"},
+		{line: "twice(a)", expected: "10\n"},
+		{line: "1 2", err: "[line 1] Error at '2': Expect ';' after expression."},
+	} {
+		buf.Reset()
+		err := session.eval([]byte(tc.line))
+		if tc.err != "" {
+			assert.EqualError(t, err, tc.err, tc.line)
+		} else {
+			assert.NoError(t, err, tc.line)
+		}
+		assert.Equal(t, tc.expected, buf.String(), tc.line)
+	}
+}
+
+func TestIncomplete(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		input      string
+		incomplete bool
+	}{
+		{input: "fun f() {", incomplete: true},
+		{input: "print (1 +", incomplete: true},
+		{input: "var a = 1", incomplete: true},
+		{input: "print \"open", incomplete: true},
+		{input: "class A {\n  m() {}\n", incomplete: true},
+		{input: "1 + 2", incomplete: false},
+		{input: "print 1;", incomplete: false},
+		{input: "print ) {", incomplete: false},
+		{input: "var a = 1;\n@ {", incomplete: false},
+		{input: "{ var b = b; }", incomplete: false},
+	} {
+		err := newREPL(io.Discard, io.Discard).eval([]byte(tc.input))
+		assert.Equal(t, tc.incomplete, incomplete(err), tc.input)
+	}
+}
+
+func TestREPLCommands(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	newREPL(&buf, &buf).run(strings.NewReader(strings.Join([]string{
+		"var b = 2;",
+		"var a = 1;",
+		":env",
+		":tokens a+1",
+		":ast print -a;",
+		":reset",
+		":env",
+		":undo",
+	}, "\n")))
+
+	assert.Equal(t, strings.Join([]string{
+		"> > > a = 1",
+		"b = 2",
+		"clock = <native fn>",
+		"> 1:1\tidentifier\t\"a\"",
+		"1:2\t+\t\"+\"",
+		"1:3\tnumber\t\"1\"",
+		"1:4\teof\t\"\"",
+		"> (print (- a))",
+		"> > clock = <native fn>",
+		"> unknown command :undo, expected one of :env, :tokens, :ast, :load, :reset or :time",
+		"> ",
+	}, "\n"), buf.String())
+}
+
+func TestREPLHistoryNotTerminal(t *testing.T) {
+	t.Parallel()
+
+	file := filepath.Join(t.TempDir(), "history")
+	require.NoError(t, os.WriteFile(file, []byte("print 1;\n"), 0o644))
+
+	session := newREPL(io.Discard, io.Discard)
+	session.historyFile = file
+	session.run(strings.NewReader("print 2;\n"))
+
+	history, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, "print 1;\n", string(history))
+}
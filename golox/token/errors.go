@@ -0,0 +1,73 @@
+package token
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Error is a diagnostic at a position in the source. The implementation is
+// based on the Go language scanner (go/scanner)
+type Error struct {
+	Pos   Position
+	Where string // location description, e.g. " at 'foo'", empty if not applicable
+	Msg   string
+
+	// Incomplete is set if the source ended before the construct was
+	// complete, so more input might resolve the error
+	Incomplete bool
+}
+
+// Error formats the diagnostic like the reference implementation
+func (e Error) Error() string {
+	return fmt.Sprintf("[line %d] Error%s: %s", e.Pos.Line, e.Where, e.Msg)
+}
+
+// At describes the location of a diagnostic reported at the token
+func At(tok Token) string {
+	if tok.Type == EOF {
+		return " at end"
+	}
+	return fmt.Sprintf(" at '%s'", tok.Lexeme)
+}
+
+// ErrorList collects the diagnostics of a compilation phase, so all of them
+// can be reported at once instead of only the first
+type ErrorList []*Error
+
+func (l *ErrorList) Add(pos Position, where string, msg string) {
+	*l = append(*l, &Error{Pos: pos, Where: where, Msg: msg})
+}
+
+func (l ErrorList) Len() int {
+	return len(l)
+}
+
+func (l ErrorList) Less(i, j int) bool {
+	return l[i].Pos.Offset < l[j].Pos.Offset
+}
+
+func (l ErrorList) Swap(i, j int) {
+	l[i], l[j] = l[j], l[i]
+}
+
+// Sort orders the list by position in the source
+func (l ErrorList) Sort() {
+	sort.Stable(l)
+}
+
+func (l ErrorList) Error() string {
+	msgs := make([]string, len(l))
+	for i, e := range l {
+		msgs[i] = e.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Err returns an error equivalent to the list, or nil if the list is empty
+func (l ErrorList) Err() error {
+	if len(l) == 0 {
+		return nil
+	}
+	return l
+}
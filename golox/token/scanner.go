@@ -5,15 +5,26 @@ import (
 	"strconv"
 )
 
+// Mode controls the scanner behaviour
+type Mode uint
+
+const (
+	ScanComments Mode = 1 << iota // return comments as Comment tokens
+)
+
 // Scanner tokenizes Lox source code. The implementation is based on the
 // Go language scanner (go/scanner)
 type Scanner struct {
+	Mode Mode
+
 	src []byte
 
 	offset     int // current read offset
 	prevOffset int // first character of current lexeme being scanned
 	lineOffset int // offset of first character of the current line
 	lineNumber int // current line number, starting at 1
+
+	errors ErrorList
 }
 
 func NewScanner(src []byte) *Scanner {
@@ -23,13 +34,16 @@ func NewScanner(src []byte) *Scanner {
 	}
 }
 
-func (s *Scanner) Scan() []Token {
+// Scan tokenizes the complete source. Lexical errors do not stop the scanner:
+// the offending lexeme becomes an Illegal token and scanning continues, so
+// the returned error lists every error in the source.
+func (s *Scanner) Scan() ([]Token, error) {
 	var tokens []Token
 	for {
 		t := s.scanToken()
 		tokens = append(tokens, t)
 		if t.Type == EOF {
-			return tokens
+			return tokens, s.errors.Err()
 		}
 	}
 }
@@ -37,8 +51,10 @@ func (s *Scanner) Scan() []Token {
 func (s *Scanner) scanToken() (tok Token) {
 	s.skipWhiteSpace()
 
+	tok.Pos = s.pos()
 	if s.eof() {
 		tok.Type = EOF
+		tok.End = tok.Pos
 		return tok
 	}
 
@@ -52,11 +68,8 @@ func (s *Scanner) scanToken() (tok Token) {
 			tok.Literal = lit
 		}
 	} else if isDecimal(ch) {
-		lit, err := s.scanNumber()
-		if err == nil {
-			t = Number
-			tok.Literal = lit
-		}
+		t = Number
+		tok.Literal = s.scanNumber()
 	} else {
 		switch ch {
 		case '(':
@@ -90,33 +103,58 @@ func (s *Scanner) scanToken() (tok Token) {
 			t = s.matchNext('=', GreaterEqual, Greater)
 		// slash
 		case '/':
-			if next, ok := s.peekNext(); ok && next == '/' {
+			if next, ok := s.peekNext(); !ok || next != '/' {
 				t = Slash
 				break
 			}
-			// comments last until a newline
-			for next, ok := s.peekNext(); ok && next != '\n'; {
+			if !s.scanComment() {
+				s.next()
+				return s.scanToken()
+			}
+			t = Comment
+		case '#':
+			// a shebang line makes a script executable, to Lox it is a comment
+			if next, ok := s.peekNext(); tok.Pos.Offset != 0 || !ok || next != '!' {
+				s.errors.Add(tok.Pos, "", "Unexpected character.")
+				t = Illegal
+				break
+			}
+			if !s.scanComment() {
 				s.next()
+				return s.scanToken()
 			}
+			t = Comment
 		case '"':
-			t = String
 			lit, err := s.scanString()
 			if err != nil {
-				tok.Type = Illegal
+				s.errors = append(s.errors, &Error{Pos: tok.Pos, Msg: err.Error(), Incomplete: true})
+				t = Illegal
 				break
 			}
-			tok.Type = String
+			t = String
 			tok.Literal = lit
 		default:
+			s.errors.Add(tok.Pos, "", "Unexpected character.")
 			t = Illegal
 		}
 	}
 
 	tok.Type = t
 	s.next()
+	tok.End = s.pos()
+	tok.Lexeme = string(s.src[tok.Pos.Offset:tok.End.Offset])
 	return tok
 }
 
+// pos returns the position of the character at the current offset
+func (s *Scanner) pos() Position {
+	return Position{
+		Offset: s.offset,
+		Line:   s.lineNumber,
+		Column: s.offset - s.lineOffset + 1,
+	}
+}
+
 // read the next ascii char
 func (s *Scanner) next() {
 	if !s.eof() {
@@ -175,14 +213,20 @@ func (s *Scanner) scanString() (string, error) {
 	// opening " is already consumed
 	s.next()
 	for {
-		if s.offset == len(s.src) || s.src[s.offset] == '\n' {
-			return "", errors.New("string literal not terminated")
+		if s.offset == len(s.src) {
+			return "", errors.New("Unterminated string.")
 		}
 
-		if s.src[s.offset] == '"' {
+		ch := s.src[s.offset]
+		if ch == '"' {
 			break
 		}
-		// we can increment without using next() because newlines are illegal
+		// strings may span multiple lines. The line is tracked here because
+		// next() would also move the start of the lexeme.
+		if ch == '\n' {
+			s.lineOffset = s.offset + 1
+			s.lineNumber += 1
+		}
 		s.offset += 1
 	}
 
@@ -190,22 +234,39 @@ func (s *Scanner) scanString() (string, error) {
 	return lit, nil
 }
 
-func (s *Scanner) scanNumber() (float64, error) {
-	if s.offset == len(s.src)-1 {
-		literal := string(s.src[s.prevOffset:s.offset])
-		return strconv.ParseFloat(literal, 64)
+// scanComment advances to the last character of the comment at s.offset,
+// which lasts until a newline. It reports whether the comment is returned as
+// a token.
+func (s *Scanner) scanComment() bool {
+	for s.offset+1 < len(s.src) && s.src[s.offset+1] != '\n' {
+		s.offset += 1
 	}
+	return s.Mode&ScanComments != 0
+}
 
-	for s.offset+1 < len(s.src) {
-		ch := rune(s.src[s.offset+1])
-		if !isDecimal(ch) && ch != '.' {
-			break
-		}
+// scanNumber reads the number literal at s.offset. The fractional part is
+// optional, but a '.' is only part of the number if a digit follows it, so
+// "123." scans as a number followed by a dot. Like the reference
+// implementation, a literal too large for a float64 is infinite.
+func (s *Scanner) scanNumber() float64 {
+	s.skipDigits()
+	if next, ok := s.peekNext(); ok && next == '.' && s.offset+2 < len(s.src) && isDecimal(rune(s.src[s.offset+2])) {
 		s.offset += 1
+		s.skipDigits()
 	}
+
+	// the literal is well-formed, so the only error is ErrRange, which comes
+	// with an infinite result
 	literal := string(s.src[s.prevOffset : s.offset+1])
-	s.prevOffset = s.offset
-	return strconv.ParseFloat(literal, 64)
+	f, _ := strconv.ParseFloat(literal, 64)
+	return f
+}
+
+// skipDigits advances to the last decimal digit following s.offset
+func (s *Scanner) skipDigits() {
+	for s.offset+1 < len(s.src) && isDecimal(rune(s.src[s.offset+1])) {
+		s.offset += 1
+	}
 }
 
 // return true if rune is an alphabetic character or underscore
@@ -1,6 +1,8 @@
 package token
 
 import (
+	"math"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -51,11 +53,29 @@ func TestScanTokens(t *testing.T) {
 			Src:      `beans = "toast";`,
 			Tokens:   []Type{Identifier, Equal, String, Semicolon},
 			Literals: []any{"beans", nil, "toast", nil},
+		}, {
+			Name:   "division and comments",
+			Src:    "a / b // comment / * \n* // trailing",
+			Tokens: []Type{Identifier, Slash, Identifier, Star},
+		}, {
+			Name:     "numbers",
+			Src:      "1.5 + 123.foo - 7",
+			Tokens:   []Type{Number, Plus, Number, Dot, Identifier, Minus, Number},
+			Literals: []any{1.5, nil, float64(123), nil, "foo", nil, float64(7)},
 		}, {
 			Name:     "variable declaration",
 			Src:      "var hello = \"world\";",
 			Tokens:   []Type{Var, Identifier, Equal, String, Semicolon},
 			Literals: []any{nil, "hello", nil, "world", nil},
+		}, {
+			Name:     "number out of range",
+			Src:      strings.Repeat("9", 400) + " 1",
+			Tokens:   []Type{Number, Number},
+			Literals: []any{math.Inf(1), float64(1)},
+		}, {
+			Name:   "shebang",
+			Src:    "#!/usr/bin/env golox\nprint 1;",
+			Tokens: []Type{Print, Number, Semicolon},
 		},
 	} {
 		tc := tc
@@ -63,7 +83,8 @@ func TestScanTokens(t *testing.T) {
 			t.Parallel()
 
 			s := NewScanner([]byte(tc.Src))
-			res := s.Scan()
+			res, err := s.Scan()
+			assert.NoError(t, err)
 
 			// token types
 			var expectedTokens []string
@@ -94,3 +115,106 @@ func TestScanTokens(t *testing.T) {
 		})
 	}
 }
+
+func TestScanPositions(t *testing.T) {
+	t.Parallel()
+
+	src := "var a = \"multi\nline\";\n  print a >= 1;"
+	s := NewScanner([]byte(src))
+	res, err := s.Scan()
+	assert.NoError(t, err)
+
+	type span struct {
+		Pos Position
+		End Position
+	}
+	expected := []span{
+		{Position{0, 1, 1}, Position{3, 1, 4}},     // var
+		{Position{4, 1, 5}, Position{5, 1, 6}},     // a
+		{Position{6, 1, 7}, Position{7, 1, 8}},     // =
+		{Position{8, 1, 9}, Position{20, 2, 6}},    // "multi\nline"
+		{Position{20, 2, 6}, Position{21, 2, 7}},   // ;
+		{Position{24, 3, 3}, Position{29, 3, 8}},   // print
+		{Position{30, 3, 9}, Position{31, 3, 10}},  // a
+		{Position{32, 3, 11}, Position{34, 3, 13}}, // >=
+		{Position{35, 3, 14}, Position{36, 3, 15}}, // 1
+		{Position{36, 3, 15}, Position{37, 3, 16}}, // ;
+		{Position{37, 3, 16}, Position{37, 3, 16}}, // eof
+	}
+
+	var actual []span
+	for _, tok := range res {
+		actual = append(actual, span{tok.Pos, tok.End})
+	}
+	assert.Equal(t, expected, actual)
+	assert.Equal(t, "multi\nline", res[3].Literal)
+}
+
+func TestScanComments(t *testing.T) {
+	t.Parallel()
+
+	s := NewScanner([]byte("// leading\nprint 1; // trailing\n//"))
+	s.Mode = ScanComments
+	res, err := s.Scan()
+	assert.NoError(t, err)
+
+	var types []Type
+	var lexemes []string
+	for _, tok := range res {
+		types = append(types, tok.Type)
+		lexemes = append(lexemes, tok.Lexeme)
+	}
+	assert.Equal(t, []Type{Comment, Print, Number, Semicolon, Comment, Comment, EOF}, types)
+	assert.Equal(t, []string{"// leading", "print", "1", ";", "// trailing", "//", ""}, lexemes)
+	assert.Equal(t, Position{20, 2, 10}, res[4].Pos)
+}
+
+func TestScanErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		Name   string
+		Src    string
+		Tokens []Type
+		Errors ErrorList
+	}{
+		{
+			Name:   "unexpected characters",
+			Src:    "a | b\n@",
+			Tokens: []Type{Identifier, Illegal, Identifier, Illegal},
+			Errors: ErrorList{
+				{Pos: Position{2, 1, 3}, Msg: "Unexpected character."},
+				{Pos: Position{6, 2, 1}, Msg: "Unexpected character."},
+			},
+		}, {
+			Name:   "unterminated string",
+			Src:    "print\n\"this string has no close quote",
+			Tokens: []Type{Print, Illegal},
+			Errors: ErrorList{
+				{Pos: Position{6, 2, 1}, Msg: "Unterminated string.", Incomplete: true},
+			},
+		}, {
+			Name:   "shebang after the first line",
+			Src:    "print 1;\n#!",
+			Tokens: []Type{Print, Number, Semicolon, Illegal, Bang},
+			Errors: ErrorList{
+				{Pos: Position{9, 2, 1}, Msg: "Unexpected character."},
+			},
+		},
+	} {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			s := NewScanner([]byte(tc.Src))
+			res, err := s.Scan()
+
+			var actualTokens []Type
+			for _, tok := range res {
+				actualTokens = append(actualTokens, tok.Type)
+			}
+			assert.Equal(t, append(tc.Tokens, EOF), actualTokens)
+			assert.Equal(t, tc.Errors, err)
+		})
+	}
+}
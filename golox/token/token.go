@@ -6,8 +6,10 @@ import (
 
 type Token struct {
 	Type    Type
+	Lexeme  string // source text of the token
 	Literal any
-	Pos     Position
+	Pos     Position // position of the first character
+	End     Position // position immediately after the last character
 }
 
 type Position struct {
@@ -16,12 +18,17 @@ type Position struct {
 	Column int // column number, starting at 1
 }
 
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
 type Type int
 
 const (
 	// Special tokens
 	Illegal Type = iota
 	EOF
+	Comment // only returned if the scanner mode includes ScanComments
 
 	// Single-character tokens
 	LeftParen
@@ -75,6 +82,7 @@ const (
 var tokens = [...]string{
 	Illegal: "illegal",
 	EOF:     "eof",
+	Comment: "comment",
 
 	LeftParen:  "(",
 	RightParen: ")",
@@ -142,3 +150,12 @@ func Lookup(ident string) Type {
 	}
 	return Identifier
 }
+
+// Keywords returns the reserved words of the language
+func Keywords() []string {
+	words := make([]string, 0, keyword_end-(keyword_beg+1))
+	for i := keyword_beg + 1; i < keyword_end; i++ {
+		words = append(words, tokens[i])
+	}
+	return words
+}
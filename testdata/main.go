@@ -2,5 +2,5 @@ package testdata
 
 import "embed"
 
-//go:embed *.lox
+//go:embed *.lox */*.lox
 var TestCases embed.FS